@@ -3,6 +3,7 @@ package app
 import (
 	"os"
 	"peak-auth/auth"
+	"peak-auth/ratelimit"
 	"peak-auth/repository"
 	"peak-auth/service"
 
@@ -10,16 +11,20 @@ import (
 )
 
 type App struct {
-	DB           *gorm.DB
-	UserService  service.UserService
-	AppService   service.ApplicationService
-	SetupService service.SetupService
-	RuleService  service.ApplicationRuleService
-	UarRepo      repository.UserApplicationRoleRepository
-	AppRepo      repository.ApplicationRepository
-	TokenManager *auth.JWTManager
-	RoleService  service.RoleService
-	EmailService *service.EmailService
+	DB                   *gorm.DB
+	UserService          service.UserService
+	AppService           service.ApplicationService
+	SetupService         service.SetupService
+	RuleService          service.ApplicationRuleService
+	UarRepo              repository.UserApplicationRoleRepository
+	AppRepo              repository.ApplicationRepository
+	TokenManager         *auth.JWTManager
+	RoleService          service.RoleService
+	PermissionService    service.PermissionService
+	GroupService         service.GroupService
+	AuthorizationService service.AuthorizationService
+	EmailService         *service.EmailService
+	RateLimiter          ratelimit.Store
 }
 
 func NewApp(db *gorm.DB, jwtManager *auth.JWTManager) *App {
@@ -35,27 +40,53 @@ func NewApp(db *gorm.DB, jwtManager *auth.JWTManager) *App {
 	passRepo := repository.NewPasswordResetRepository(db)
 	setupRepo := repository.NewSetupRepository(db)
 	refreshRepo := repository.NewRefreshTokenRepository(db)
+	lockoutRepo := repository.NewUserLockoutRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	deviceRepo := repository.NewUserDeviceRepository(db)
+	emailChangeRepo := repository.NewEmailChangeRepository(db)
+	accountExportRepo := repository.NewAccountExportRepository(db)
+	accountDeletionRepo := repository.NewAccountDeletionRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	attributeRepo := repository.NewUserAttributeRepository(db)
+	impersonationRepo := repository.NewImpersonationRepository(db)
+	permissionRepo := repository.NewPermissionRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
 	txManager := repository.NewTransactionManager(db)
 
+	// Deny-list de access tokens consultada al validar cada JWT
+	jwtManager.SetRevocationStore(repository.NewAccessTokenRevocationRepository(db))
+
 	// 2. Inicializar Servicios inyectando los repos
 	ruleService := service.NewApplicationRuleService(ruleRepo, uarRepo, roleRepo)
 
 	emailService := service.NewEmailService()
-	appService := service.NewApplicationService(appRepo, userRepo, roleRepo, uarRepo, txManager, emailService, passRepo)
-	userService := service.NewUserService(userRepo, roleRepo, uarRepo, appRepo, ruleService, jwtManager, emailRepo, passRepo, emailService, refreshRepo)
+	challengeManager := auth.NewChallengeManager()
+	var captchaVerifier auth.CaptchaVerifier
+	if v := auth.NewCaptchaVerifierFromEnv(); v != nil {
+		captchaVerifier = v
+	}
+	appService := service.NewApplicationService(appRepo, userRepo, roleRepo, uarRepo, txManager, emailService, passRepo, invitationRepo, ruleService)
+	userService := service.NewUserService(userRepo, roleRepo, uarRepo, appRepo, ruleService, jwtManager, emailRepo, passRepo, emailService, refreshRepo, lockoutRepo, loginAttemptRepo, challengeManager, captchaVerifier, deviceRepo, emailChangeRepo, accountExportRepo, accountDeletionRepo, attributeRepo, impersonationRepo, permissionRepo)
 	setupService := service.NewSetupService(setupRepo, setupToken, txManager)
 	roleService := service.NewRoleService(roleRepo)
+	permissionService := service.NewPermissionService(permissionRepo, appRepo, roleRepo)
+	groupService := service.NewGroupService(groupRepo, userRepo, appRepo, roleRepo)
+	authorizationService := service.NewAuthorizationService(userRepo, uarRepo, permissionRepo, ruleService)
 
 	return &App{
-		DB:           db,
-		UserService:  userService,
-		AppService:   appService,
-		SetupService: setupService,
-		RuleService:  ruleService,
-		TokenManager: jwtManager,
-		UarRepo:      uarRepo,
-		AppRepo:      appRepo,
-		RoleService:  roleService,
-		EmailService: emailService,
+		DB:                   db,
+		UserService:          userService,
+		AppService:           appService,
+		SetupService:         setupService,
+		RuleService:          ruleService,
+		TokenManager:         jwtManager,
+		UarRepo:              uarRepo,
+		AppRepo:              appRepo,
+		RoleService:          roleService,
+		PermissionService:    permissionService,
+		GroupService:         groupService,
+		AuthorizationService: authorizationService,
+		EmailService:         emailService,
+		RateLimiter:          ratelimit.NewStoreFromEnv(db),
 	}
 }
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// CaptchaVerifier valida el token que un widget CAPTCHA entrega al cliente.
+type CaptchaVerifier interface {
+	Verify(token, remoteIP string) (bool, error)
+}
+
+// SiteVerifyCaptcha implementa el protocolo "siteverify" compartido por hCaptcha,
+// Cloudflare Turnstile y reCAPTCHA.
+type SiteVerifyCaptcha struct {
+	VerifyURL string
+	Secret    string
+	SiteKey   string
+	client    *http.Client
+}
+
+// NewCaptchaVerifierFromEnv construye el verificador a partir de CAPTCHA_VERIFY_URL,
+// CAPTCHA_SECRET y CAPTCHA_SITE_KEY. Devuelve nil si no está configurado.
+func NewCaptchaVerifierFromEnv() *SiteVerifyCaptcha {
+	verifyURL := os.Getenv("CAPTCHA_VERIFY_URL")
+	secret := os.Getenv("CAPTCHA_SECRET")
+	if verifyURL == "" || secret == "" {
+		return nil
+	}
+	return &SiteVerifyCaptcha{
+		VerifyURL: verifyURL,
+		Secret:    secret,
+		SiteKey:   os.Getenv("CAPTCHA_SITE_KEY"),
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Verify consulta al proveedor si el token es válido.
+func (v *SiteVerifyCaptcha) Verify(token, remoteIP string) (bool, error) {
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	resp, err := v.client.PostForm(v.VerifyURL, form)
+	if err != nil {
+		return false, fmt.Errorf("error consultando el proveedor de CAPTCHA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("respuesta inválida del proveedor de CAPTCHA: %w", err)
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/bits"
+	"os"
+	"strings"
+	"time"
+)
+
+// ChallengeManager emite y verifica desafíos de prueba de trabajo (proof-of-work) sin
+// estado: el desafío viaja firmado con HMAC, por lo que cualquier instancia que comparta
+// CHALLENGE_SECRET puede validarlo.
+type ChallengeManager struct {
+	secret []byte
+}
+
+// Challenge es el desafío que el cliente debe resolver: encontrar una solución tal que
+// SHA-256(token + solution) comience con Difficulty bits en cero.
+type Challenge struct {
+	Token      string    `json:"token"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Algorithm  string    `json:"algorithm"`
+}
+
+type challengePayload struct {
+	Nonce      string `json:"n"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"e"`
+	Scope      string `json:"s"`
+}
+
+// MaxChallengeDifficulty evita desafíos que un navegador no pueda resolver en tiempo razonable.
+const MaxChallengeDifficulty = 26
+
+// NewChallengeManager lee la clave HMAC de CHALLENGE_SECRET. Si no está definida genera
+// una aleatoria, válida sólo para esta instancia.
+func NewChallengeManager() *ChallengeManager {
+	secret := []byte(os.Getenv("CHALLENGE_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("no se pudo generar la clave de desafíos: %v", err)
+		}
+		log.Println("⚠️  CHALLENGE_SECRET no definido: los desafíos sólo serán válidos en esta instancia")
+	}
+	return &ChallengeManager{secret: secret}
+}
+
+// Issue crea un desafío atado a scope (p.ej. app + email) con la dificultad indicada en bits.
+func (m *ChallengeManager) Issue(scope string, difficulty int, ttl time.Duration) (Challenge, error) {
+	if difficulty > MaxChallengeDifficulty {
+		difficulty = MaxChallengeDifficulty
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return Challenge{}, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	payload, err := json.Marshal(challengePayload{
+		Nonce:      base64.RawURLEncoding.EncodeToString(nonce),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt.Unix(),
+		Scope:      scope,
+	})
+	if err != nil {
+		return Challenge{}, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return Challenge{
+		Token:      encoded + "." + m.sign(encoded),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt,
+		Algorithm:  "sha256(token + solution)",
+	}, nil
+}
+
+// Verify comprueba firma, vencimiento, scope, dificultad mínima y la solución del desafío.
+func (m *ChallengeManager) Verify(token, solution, scope string, minDifficulty int) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return fmt.Errorf("desafío malformado")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(m.sign(parts[0]))) {
+		return fmt.Errorf("firma del desafío inválida")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("desafío malformado")
+	}
+	var payload challengePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("desafío malformado")
+	}
+
+	if time.Now().Unix() > payload.ExpiresAt {
+		return fmt.Errorf("el desafío expiró")
+	}
+	if payload.Scope != scope {
+		return fmt.Errorf("el desafío no corresponde a esta solicitud")
+	}
+	if payload.Difficulty < minDifficulty && payload.Difficulty < MaxChallengeDifficulty {
+		return fmt.Errorf("la dificultad del desafío es insuficiente")
+	}
+
+	sum := sha256.Sum256([]byte(token + solution))
+	if leadingZeroBits(sum[:]) < payload.Difficulty {
+		return fmt.Errorf("solución del desafío incorrecta")
+	}
+	return nil
+}
+
+func (m *ChallengeManager) sign(data string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func leadingZeroBits(b []byte) int {
+	count := 0
+	for _, by := range b {
+		if by == 0 {
+			count += 8
+			continue
+		}
+		return count + bits.LeadingZeros8(by)
+	}
+	return count
+}
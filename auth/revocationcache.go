@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"container/list"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// revocationCacheTTL es cuánto puede tardar una revocación en verse en esta instancia.
+	revocationCacheTTL = 5 * time.Second
+	// revocationCacheSize acota la memoria: se descartan los usuarios usados hace más tiempo.
+	revocationCacheSize = 10000
+)
+
+// cachedRevocationStore evita un viaje a la base por cada VerifyToken guardando la respuesta
+// de la deny-list por usuario durante revocationCacheTTL (LRU de revocationCacheSize entradas).
+//
+// Ante un error de la base falla CERRADO: el token se rechaza, porque la deny-list existe para
+// cortar el acceso de usuarios desactivados o sesiones comprometidas. Para que un corte breve
+// no tumbe todas las sesiones se usa la última respuesta conocida del usuario aunque haya vencido.
+type cachedRevocationStore struct {
+	store RevocationStore
+	mu    sync.Mutex
+	order *list.List
+	items map[uint]*list.Element
+}
+
+type revocationEntry struct {
+	userID    uint
+	before    time.Time
+	revoked   bool
+	fetchedAt time.Time
+}
+
+func newCachedRevocationStore(store RevocationStore) *cachedRevocationStore {
+	return &cachedRevocationStore{store: store, order: list.New(), items: make(map[uint]*list.Element)}
+}
+
+// RevokedBefore responde desde la cache si la entrada es reciente y si no consulta el store.
+func (c *cachedRevocationStore) RevokedBefore(userID uint) (time.Time, bool, error) {
+	now := time.Now()
+	c.mu.Lock()
+	elem, cached := c.items[userID]
+	if cached {
+		c.order.MoveToFront(elem)
+		if entry := elem.Value.(*revocationEntry); now.Sub(entry.fetchedAt) < revocationCacheTTL {
+			c.mu.Unlock()
+			return entry.before, entry.revoked, nil
+		}
+	}
+	c.mu.Unlock()
+
+	before, revoked, err := c.store.RevokedBefore(userID)
+	if err != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if elem, ok := c.items[userID]; ok {
+			entry := elem.Value.(*revocationEntry)
+			log.Printf("deny-list: error consultando el usuario %d, se usa la respuesta de hace %s: %v", userID, now.Sub(entry.fetchedAt).Round(time.Second), err)
+			return entry.before, entry.revoked, nil
+		}
+		return time.Time{}, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &revocationEntry{userID: userID, before: before, revoked: revoked, fetchedAt: now}
+	if elem, ok := c.items[userID]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[userID] = c.order.PushFront(entry)
+		if c.order.Len() > revocationCacheSize {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*revocationEntry).userID)
+		}
+	}
+	return before, revoked, nil
+}
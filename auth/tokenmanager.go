@@ -12,8 +12,15 @@ import (
 
 // JWTManager gestiona la generación y validación de tokens JWT.
 type JWTManager struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
+	privateKey  *rsa.PrivateKey
+	publicKey   *rsa.PublicKey
+	revocations RevocationStore
+}
+
+// RevocationStore indica desde cuándo se revocaron los access tokens de un usuario
+// (deny-list por usuario: p.ej. al desactivarlo desde la consola).
+type RevocationStore interface {
+	RevokedBefore(userID uint) (time.Time, bool, error)
 }
 
 // CustomClaims define qué info viajará en el token
@@ -21,9 +28,29 @@ type CustomClaims struct {
 	Username string   `json:"username"`
 	AppID    string   `json:"app_id"`
 	Roles    []string `json:"roles"`
+	// Atributos personalizados marcados con in_token en la ATTRIBUTE_SCHEMA de la app
+	Attributes map[string]interface{} `json:"attrs,omitempty"`
+	// Permisos de los roles del usuario en la app: como lista y como scope OAuth (separados por espacio)
+	Permissions []string `json:"permissions,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	// Admin que actúa en nombre del usuario (suplantación, RFC 8693 "act")
+	Act *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaim identifica al actor de un token emitido por suplantación (RFC 8693 §4.1).
+type ActorClaim struct {
+	Subject  string `json:"sub"`
+	Username string `json:"username,omitempty"`
+}
+
+// TokenExtras agrupa los claims opcionales de un access token.
+type TokenExtras struct {
+	Attributes  map[string]interface{}
+	Permissions []string
+	Actor       *ActorClaim
+}
+
 // NewJWTManager crea una nueva instancia de JWTManager.
 // Lee la clave privada RSA (en formato PEM) desde la variable de entorno JWT_PRIVATE_KEY.
 //
@@ -63,12 +90,28 @@ func NewJWTManager() (*JWTManager, error) {
 	}, nil
 }
 
+// SetRevocationStore habilita la comprobación de la deny-list en VerifyToken. Las respuestas se
+// cachean unos segundos por usuario (ver cachedRevocationStore, que también define qué pasa si
+// el store falla).
+func (m *JWTManager) SetRevocationStore(store RevocationStore) {
+	m.revocations = newCachedRevocationStore(store)
+}
+
 // GenerateToken crea un nuevo token JWT para un usuario y aplicación específicos.
 func (m *JWTManager) GenerateToken(userID uint, username string, appID string, roles []string, duration time.Duration) (string, error) {
+	return m.GenerateTokenWithExtras(userID, username, appID, roles, TokenExtras{}, duration)
+}
+
+// GenerateTokenWithExtras crea un token JWT sumando los claims opcionales.
+func (m *JWTManager) GenerateTokenWithExtras(userID uint, username string, appID string, roles []string, extras TokenExtras, duration time.Duration) (string, error) {
 	claims := CustomClaims{
-		Username: username,
-		AppID:    appID,
-		Roles:    roles,
+		Username:    username,
+		AppID:       appID,
+		Roles:       roles,
+		Attributes:  extras.Attributes,
+		Permissions: extras.Permissions,
+		Scope:       strings.Join(extras.Permissions, " "),
+		Act:         extras.Actor,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   fmt.Sprintf("%d", userID),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
@@ -108,5 +151,31 @@ func (m *JWTManager) VerifyToken(tokenString string) (*CustomClaims, error) {
 
 	// Como pasamos el puntero 'claims' al inicio, si token.Valid es true,
 	// 'claims' ya tiene los datos cargados. No hace falta casting.
+	if err := m.checkRevocation(claims); err != nil {
+		return nil, err
+	}
 	return claims, nil
 }
+
+// checkRevocation rechaza los tokens emitidos antes de la revocación del usuario.
+func (m *JWTManager) checkRevocation(claims *CustomClaims) error {
+	if m.revocations == nil {
+		return nil
+	}
+
+	var userID uint
+	if _, err := fmt.Sscanf(claims.Subject, "%d", &userID); err != nil {
+		return fmt.Errorf("token inválido")
+	}
+
+	// Falla cerrado: sin poder consultar la deny-list el token no se acepta
+	before, revoked, err := m.revocations.RevokedBefore(userID)
+	if err != nil {
+		return fmt.Errorf("no se pudo comprobar la revocación del token: %w", err)
+	}
+	// iat tiene resolución de segundos: ante la duda el token se considera revocado
+	if revoked && (claims.IssuedAt == nil || !claims.IssuedAt.Time.After(before)) {
+		return fmt.Errorf("token revocado")
+	}
+	return nil
+}
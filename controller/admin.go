@@ -1,23 +1,33 @@
 package controller
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"peak-auth/auth"
+	"peak-auth/request"
 	"peak-auth/response"
 	"peak-auth/service"
 	"peak-auth/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AdminController struct
 type AdminController struct {
-	UserService service.UserService
-	AppService  service.ApplicationService
-	RuleService service.ApplicationRuleService
-	RoleService service.RoleService
+	UserService       service.UserService
+	AppService        service.ApplicationService
+	RuleService       service.ApplicationRuleService
+	RoleService       service.RoleService
+	PermissionService service.PermissionService
+	GroupService      service.GroupService
 }
 
 // Dashboard renderiza el dashboard
@@ -45,6 +55,7 @@ func (ctrl *AdminController) Dashboard(c *gin.Context) {
 
 	ctrl.renderAdmin(c, "dashboard.html", gin.H{
 		"Applications": stats,
+		"IsRoot":       rootStatus,
 		"Breadcrumbs":  nil,
 		"Title":        "Dashboard",
 	})
@@ -277,13 +288,17 @@ func (ctrl *AdminController) GetAppDetails(c *gin.Context) {
 	}
 	rules, _ := ctrl.RuleService.FindRulesByAppID(app.ID)
 	users, _ := ctrl.UserService.FindUserByAppID(id)
-	roles, _ := ctrl.RoleService.FindAll()
+	roles, _ := ctrl.RoleService.FindForApp(app.ID)
+	permissions, _ := ctrl.PermissionService.GetCatalog(id)
 
 	// Pre-procesar reglas para la vista
 	var regPolicy *utils.RegistrationPolicy
 	var pwdPolicy *utils.PasswordPolicy
 	var sessionPolicy *utils.SessionPolicy
 	var authzPolicy *utils.AuthzPolicy
+	var attributeSchema *utils.AttributeSchema
+	var attributeSchemaJSON string
+	var loginPolicy *utils.LoginPolicy
 
 	for _, r := range rules {
 		switch r.Code {
@@ -298,18 +313,31 @@ func (ctrl *AdminController) GetAppDetails(c *gin.Context) {
 			sessionPolicy, _ = utils.ParseSessionPolicy(r.Value)
 		case "AUTHZ_POLICY":
 			authzPolicy, _ = utils.ParseAuthzPolicy(r.Value)
+		case "LOGIN_POLICY":
+			loginPolicy, _ = utils.ParseLoginPolicy(r.Value)
+		case "ATTRIBUTE_SCHEMA":
+			attributeSchema, _ = utils.ParseAttributeSchema(r.Value)
+			if attributeSchema != nil {
+				if pretty, err := json.MarshalIndent(attributeSchema, "", "  "); err == nil {
+					attributeSchemaJSON = string(pretty)
+				}
+			}
 		}
 	}
 
 	ctrl.renderAdmin(c, "app_show.html", gin.H{
-		"App":           app,
-		"Rules":         rules,
-		"RegPolicy":     regPolicy,
-		"PwdPolicy":     pwdPolicy,
-		"SessionPolicy": sessionPolicy,
-		"AuthzPolicy":   authzPolicy,
-		"UserCount":     len(users),
-		"Roles":         roles,
+		"App":                 app,
+		"Rules":               rules,
+		"RegPolicy":           regPolicy,
+		"PwdPolicy":           pwdPolicy,
+		"SessionPolicy":       sessionPolicy,
+		"AuthzPolicy":         authzPolicy,
+		"LoginPolicy":         loginPolicy,
+		"AttributeSchema":     attributeSchema,
+		"AttributeSchemaJSON": attributeSchemaJSON,
+		"UserCount":           len(users),
+		"Roles":               roles,
+		"Permissions":         permissions,
 		"Breadcrumbs": []gin.H{
 			{"Label": "Apps", "URL": "/admin"},
 			{"Label": app.Name},
@@ -341,21 +369,32 @@ func (ctrl *AdminController) GetAppUsers(c *gin.Context) {
 		return
 	}
 
-	roles, err := ctrl.RoleService.FindAll()
+	roles, err := ctrl.RoleService.FindForApp(app.ID)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Error al cargar los roles")
 		return
 	}
 
+	invitations, err := ctrl.AppService.ListPendingInvitations(appIDParam)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error al cargar las invitaciones")
+		return
+	}
+
+	hierarchy, err := ctrl.RoleService.HierarchyForApp(app.ID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error al cargar la herencia de roles")
+		return
+	}
+
+	attributeSchema, _ := ctrl.RuleService.FindAttributeSchema(app.ID)
+
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
 	if totalPages < 1 {
 		totalPages = 1
 	}
 
-	pagesSlice := make([]int, totalPages)
-	for i := 0; i < totalPages; i++ {
-		pagesSlice[i] = i + 1
-	}
+	pagesSlice := pageWindow(page, totalPages)
 
 	nextPg := page + 1
 	if nextPg > totalPages {
@@ -367,15 +406,18 @@ func (ctrl *AdminController) GetAppUsers(c *gin.Context) {
 	}
 
 	ctrl.renderAdmin(c, "users.html", gin.H{
-		"App":        app,
-		"Users":      users,
-		"TotalCount": total,
-		"CurrentPg":  page,
-		"TotalPages": totalPages,
-		"NextPg":     nextPg,
-		"PrevPg":     prevPg,
-		"Pages":      pagesSlice,
-		"Roles":      roles,
+		"App":           app,
+		"Users":         users,
+		"TotalCount":    total,
+		"CurrentPg":     page,
+		"TotalPages":    totalPages,
+		"NextPg":        nextPg,
+		"PrevPg":        prevPg,
+		"Pages":         pagesSlice,
+		"Roles":         roles,
+		"Hierarchy":     hierarchy,
+		"Invitations":   invitations,
+		"HasAttributes": len(attributeSchema.Attributes) > 0,
 		"Breadcrumbs": []gin.H{
 			{"Label": app.Name, "URL": "/admin/apps/" + app.AppID},
 			{"Label": "Usuarios"},
@@ -398,13 +440,142 @@ func (ctrl *AdminController) PostUsersInApp(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "email y role requeridos"})
 		return
 	}
-	if err := ctrl.AppService.RegisterUserInApp(email, app.AppID, role); err != nil {
+	startsAt, okStart := parseOptionalTime(c.PostForm("starts_at"))
+	expiresAt, okExpiry := parseOptionalTime(c.PostForm("expires_at"))
+	if !okStart || !okExpiry {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "starts_at y expires_at deben ser fechas RFC 3339"})
+		return
+	}
+	invited, err := ctrl.AppService.RegisterUserInApp(email, app.AppID, role, c.GetUint("user_id"), startsAt, expiresAt)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if invited {
+		c.JSON(http.StatusOK, gin.H{"message": "El email no tiene cuenta: se le envió una invitación", "invited": true})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Usuario vinculado con éxito"})
 }
 
+// parseOptionalTime interpreta una fecha RFC 3339 opcional de un formulario; vacía es nil
+func parseOptionalTime(value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+// invitationAction ejecuta una acción sobre una invitación pendiente de la app
+func (ctrl *AdminController) invitationAction(c *gin.Context, action func(string, uint) error, message string) {
+	invitationID, err := strconv.ParseUint(c.Param("invitation_id"), 10, 64)
+	if err != nil || invitationID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de invitación inválido"})
+		return
+	}
+
+	if err := action(c.Param("id"), uint(invitationID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// PostResendInvitation reenvía una invitación con un link nuevo
+func (ctrl *AdminController) PostResendInvitation(c *gin.Context) {
+	ctrl.invitationAction(c, ctrl.AppService.ResendInvitation, "Invitación reenviada")
+}
+
+// DeleteInvitation revoca una invitación pendiente
+func (ctrl *AdminController) DeleteInvitation(c *gin.Context) {
+	ctrl.invitationAction(c, ctrl.AppService.RevokeInvitation, "Invitación revocada")
+}
+
+// maxUserImportSize limita el tamaño del archivo de importación (5 MB)
+const maxUserImportSize = 5 << 20
+
+// PostImportUsers importa usuarios a la aplicación desde un archivo CSV o JSON
+func (ctrl *AdminController) PostImportUsers(c *gin.Context) {
+	id := c.Param("id")
+
+	var opts request.UserImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "opciones de importación inválidas"})
+		return
+	}
+	opts.ByRoot = c.GetBool("is_root")
+	opts.ImportedBy = c.GetUint("user_id")
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archivo requerido"})
+		return
+	}
+	if file.Size > maxUserImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "el archivo supera los 5 MB"})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no se pudo leer el archivo"})
+		return
+	}
+	defer src.Close()
+
+	rows, err := request.ParseUserImport(format, src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := ctrl.AppService.ImportUsers(id, rows, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetExportUsers descarga los usuarios de la aplicación con sus roles (CSV o JSON)
+func (ctrl *AdminController) GetExportUsers(c *gin.Context) {
+	id := c.Param("id")
+	format := c.DefaultQuery("format", request.UserImportFormatCSV)
+
+	rows, err := ctrl.AppService.ExportUsers(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-users-%s.%s", id, time.Now().Format("20060102"), format)
+	switch format {
+	case request.UserImportFormatJSON:
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.JSON(http.StatusOK, rows)
+	case request.UserImportFormatCSV:
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"email", "first_name", "last_name", "roles", "verified", "active"})
+		for _, row := range rows {
+			w.Write([]string{row.Email, row.FirstName, row.LastName, row.RoleNames, strconv.FormatBool(row.IsVerified), strconv.FormatBool(row.IsActive)})
+		}
+		w.Flush()
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato no soportado: usá csv o json"})
+	}
+}
+
 // GetAppRules redirige a los detalles de la aplicación
 func (ctrl *AdminController) GetAppRules(c *gin.Context) {
 	id := c.Param("id")
@@ -492,6 +663,113 @@ func (ctrl *AdminController) DeleteRole(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Rol eliminado con éxito"})
 }
 
+// PostAppRole crea un rol propio de la aplicación
+func (ctrl *AdminController) PostAppRole(c *gin.Context) {
+	ctrl.appRoleAction(c, ctrl.RoleService.CreateAppRole, "Rol creado con éxito")
+}
+
+// DeleteAppRole elimina un rol propio de la aplicación si ningún usuario lo tiene asignado
+func (ctrl *AdminController) DeleteAppRole(c *gin.Context) {
+	ctrl.appRoleAction(c, ctrl.RoleService.DeleteAppRole, "Rol eliminado con éxito")
+}
+
+// appRoleAction resuelve la app de la URL y el nombre del rol del body y ejecuta la acción
+func (ctrl *AdminController) appRoleAction(c *gin.Context, action func(uint, string) error, message string) {
+	var req struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nombre de rol requerido"})
+		return
+	}
+
+	app, err := ctrl.AppService.GetAppDetails(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "App no encontrada"})
+		return
+	}
+
+	if err := action(app.ID, req.Name); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// PutRoleParents reemplaza los roles que hereda un rol propio de la aplicación
+func (ctrl *AdminController) PutRoleParents(c *gin.Context) {
+	var req request.RoleParentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	app, err := ctrl.AppService.GetAppDetails(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "App no encontrada"})
+		return
+	}
+
+	if err := ctrl.RoleService.SetAppRoleParents(app.ID, c.Param("role"), req.Parents); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Herencia del rol actualizada"})
+}
+
+// --- CATÁLOGO DE PERMISOS POR APP ---
+
+// PostPermission agrega un permiso al catálogo de la aplicación
+func (ctrl *AdminController) PostPermission(c *gin.Context) {
+	var req request.PermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: el código admite hasta 100 caracteres y la descripción 255"})
+		return
+	}
+
+	permission, err := ctrl.PermissionService.CreatePermission(c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permiso creado", "permission": permission})
+}
+
+// DeletePermission quita un permiso del catálogo y de los roles que lo tenían
+func (ctrl *AdminController) DeletePermission(c *gin.Context) {
+	permissionID, err := strconv.ParseUint(c.Param("permission_id"), 10, 64)
+	if err != nil || permissionID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de permiso inválido"})
+		return
+	}
+
+	if err := ctrl.PermissionService.DeletePermission(c.Param("id"), uint(permissionID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permiso eliminado"})
+}
+
+// PutRolePermissions reemplaza los permisos de un rol en la aplicación
+func (ctrl *AdminController) PutRolePermissions(c *gin.Context) {
+	var req request.RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	if err := ctrl.PermissionService.SetRolePermissions(c.Param("id"), c.Param("role"), req.Permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permisos del rol actualizados"})
+}
+
 // RevokeUserAccess revoca el acceso de un usuario a una aplicación
 func (ctrl *AdminController) RevokeUserAccess(c *gin.Context) {
 	appIDParam := c.Param("id")
@@ -509,10 +787,18 @@ func (ctrl *AdminController) RevokeUserAccess(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.AppService.RevokeUserFromApp(userID, app.ID); err != nil {
+	groups, err := ctrl.AppService.RevokeUserFromApp(userID, app.ID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if len(groups) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Se quitaron los roles directos, pero sigue accediendo por los grupos: %s. Sólo ROOT puede sacarlo de ellos.", strings.Join(groups, ", ")),
+			"groups":  groups,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Acceso revocado"})
 }
@@ -536,6 +822,24 @@ func (ctrl *AdminController) PostAppRule(c *gin.Context) {
 		return
 	}
 
+	switch code {
+	case "PROFILE_POLICY":
+		if _, err := utils.ParseProfilePolicy(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "ATTRIBUTE_SCHEMA":
+		if _, err := utils.ParseAttributeSchema(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "LOGIN_POLICY":
+		if _, err := utils.ParseLoginPolicy(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	err = ctrl.RuleService.CreateRule(app.ID, code, body)
 	if err != nil {
 		// Asumiendo que si el error es de gorm duplicado, devolvemos un 409
@@ -568,6 +872,9 @@ func (ctrl *AdminController) PutAppRule(c *gin.Context) {
 		var params struct {
 			TokenExpirationMinutes int `json:"token_expiration_minutes"`
 			MaxFailedLogins        int `json:"max_failed_logins"`
+			LockoutMinutes         int `json:"lockout_minutes"`
+			MaxLockoutMinutes      int `json:"max_lockout_minutes"`
+			ChallengeDifficulty    int `json:"challenge_difficulty"`
 		}
 		if err := json.Unmarshal(body, &params); err == nil {
 			if params.TokenExpirationMinutes < 1 {
@@ -578,16 +885,48 @@ func (ctrl *AdminController) PutAppRule(c *gin.Context) {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "El máximo de logins fallidos debe estar entre 1 y 10"})
 				return
 			}
+			if params.LockoutMinutes < 0 || params.MaxLockoutMinutes < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "La duración del bloqueo no puede ser negativa"})
+				return
+			}
+			if params.MaxLockoutMinutes > 0 && params.MaxLockoutMinutes < params.LockoutMinutes {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "El bloqueo máximo debe ser mayor o igual al bloqueo inicial"})
+				return
+			}
+			if params.ChallengeDifficulty < 0 || params.ChallengeDifficulty > auth.MaxChallengeDifficulty {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La dificultad del desafío debe estar entre 0 y %d bits", auth.MaxChallengeDifficulty)})
+				return
+			}
 		}
 	case "PWD_POLICY":
 		var params struct {
 			MinLength int `json:"min_length"`
+			MinScore  int `json:"min_score"`
 		}
 		if err := json.Unmarshal(body, &params); err == nil {
 			if params.MinLength < 4 {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "La contraseña debe tener al menos 4 caracteres"})
 				return
 			}
+			if params.MinScore < 0 || params.MinScore > 4 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "El puntaje mínimo de fortaleza debe estar entre 0 y 4"})
+				return
+			}
+		}
+	case "PROFILE_POLICY":
+		if _, err := utils.ParseProfilePolicy(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "ATTRIBUTE_SCHEMA":
+		if _, err := utils.ParseAttributeSchema(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "LOGIN_POLICY":
+		if _, err := utils.ParseLoginPolicy(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
@@ -636,16 +975,452 @@ func (ctrl *AdminController) renderAdmin(c *gin.Context, templateName string, da
 	c.HTML(http.StatusOK, templateName, data)
 }
 
-// PostUnlockUser resetea el contador de intentos fallidos
+// PostUnlockUser levanta el bloqueo del usuario en la aplicación
 func (ctrl *AdminController) PostUnlockUser(c *gin.Context) {
+	app, err := ctrl.AppService.GetAppDetails(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "App no encontrada"})
+		return
+	}
+
 	userIDStr := c.Param("user_id")
 	var userID uint
 	fmt.Sscanf(userIDStr, "%d", &userID)
 
-	if err := ctrl.UserService.UnlockUser(userID); err != nil {
+	if err := ctrl.UserService.UnlockUser(userID, app.ID); err != nil {
 		c.JSON(500, gin.H{"error": "No se pudo desbloquear al usuario"})
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Usuario desbloqueado correctamente"})
 }
+
+// GetUsersDirectory muestra el directorio global de usuarios con búsqueda, filtros y paginación
+func (ctrl *AdminController) GetUsersDirectory(c *gin.Context) {
+	var filter request.UserDirectoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.String(http.StatusBadRequest, "Filtros inválidos")
+		return
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	filter.Limit = 20
+
+	users, total, err := ctrl.UserService.SearchUsers(filter)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error al cargar los usuarios")
+		return
+	}
+
+	// Destinos de la acción masiva "agregar al grupo"
+	groups, err := ctrl.GroupService.ListGroups()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error al cargar los grupos")
+		return
+	}
+
+	totalPages := int((total + int64(filter.Limit) - 1) / int64(filter.Limit))
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	// Los links de paginación conservan los filtros actuales
+	pageURL := func(page int) string {
+		query := c.Request.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		return "/admin/users?" + query.Encode()
+	}
+	var pages []gin.H
+	for _, number := range pageWindow(filter.Page, totalPages) {
+		pages = append(pages, gin.H{"Number": number, "URL": pageURL(number)})
+	}
+
+	ctrl.renderAdmin(c, "users_directory.html", gin.H{
+		"Users":      users,
+		"Groups":     groups,
+		"TotalCount": total,
+		"Filter":     filter,
+		"CurrentPg":  filter.Page,
+		"TotalPages": totalPages,
+		"PrevURL":    pageURL(max(filter.Page-1, 1)),
+		"NextURL":    pageURL(min(filter.Page+1, totalPages)),
+		"Pages":      pages,
+		"Breadcrumbs": []gin.H{
+			{"Label": "Usuarios"},
+		},
+		"Title": "Directorio de usuarios",
+	})
+}
+
+// paginationWindow es la cantidad máxima de links de página que muestra el paginador.
+const paginationWindow = 7
+
+// pageWindow devuelve los números de página a mostrar, centrados en la actual y acotados a
+// paginationWindow para que el paginador no crezca con el total de registros.
+func pageWindow(current, totalPages int) []int {
+	start := max(current-paginationWindow/2, 1)
+	end := min(start+paginationWindow-1, totalPages)
+	start = max(end-paginationWindow+1, 1)
+
+	pages := make([]int, 0, end-start+1)
+	for number := start; number <= end; number++ {
+		pages = append(pages, number)
+	}
+	return pages
+}
+
+// parseUserID lee el parámetro :user_id de la ruta
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetUserDetail muestra la ficha de un usuario con sus roles, sesiones y actividad
+func (ctrl *AdminController) GetUserDetail(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.String(http.StatusBadRequest, "ID de usuario inválido")
+		return
+	}
+
+	detail, err := ctrl.UserService.GetUserDetail(userID)
+	if err != nil {
+		c.String(http.StatusNotFound, "Usuario no encontrado")
+		return
+	}
+	groups, _ := ctrl.GroupService.FindByUser(userID)
+
+	roles := c.GetStringSlice("user_roles")
+	ctrl.renderAdmin(c, "user_detail.html", gin.H{
+		"User":           detail,
+		"Groups":         groups,
+		"CanImpersonate": slices.Contains(roles, "ROOT") || slices.Contains(roles, utils.RoleImpersonator),
+		"RootAppID":      utils.AppID_PEAK_AUTH,
+		"Breadcrumbs": []gin.H{
+			{"Label": "Usuarios", "URL": "/admin/users"},
+			{"Label": detail.Email},
+		},
+		"Title": detail.Email,
+	})
+}
+
+// userAction ejecuta una acción de la ficha de usuario y responde en JSON
+func (ctrl *AdminController) userAction(c *gin.Context, action func(uint) error, message string) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	if err := action(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// PostUserResendVerification reenvía el email de verificación
+func (ctrl *AdminController) PostUserResendVerification(c *gin.Context) {
+	ctrl.userAction(c, ctrl.UserService.ResendVerification, "Email de verificación reenviado")
+}
+
+// PostUserPasswordReset envía un email de restablecimiento de contraseña
+func (ctrl *AdminController) PostUserPasswordReset(c *gin.Context) {
+	ctrl.userAction(c, ctrl.UserService.SendResetEmailByID, "Email de restablecimiento enviado")
+}
+
+// PostUserRevokeSessions cierra todas las sesiones del usuario
+func (ctrl *AdminController) PostUserRevokeSessions(c *gin.Context) {
+	ctrl.userAction(c, ctrl.UserService.RevokeAllSessions, "Sesiones revocadas")
+}
+
+// PostUserUnlockAll levanta el bloqueo del usuario en todas las aplicaciones
+func (ctrl *AdminController) PostUserUnlockAll(c *gin.Context) {
+	ctrl.userAction(c, ctrl.UserService.UnlockAll, "Usuario desbloqueado en todas las aplicaciones")
+}
+
+// PostImpersonateUser emite un token de suplantación del usuario en una aplicación
+func (ctrl *AdminController) PostImpersonateUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	var req request.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: indica la aplicación y el motivo (hasta 255 caracteres)"})
+		return
+	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	token, err := ctrl.UserService.StartImpersonation(c.GetUint("user_id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// PutUserStatus activa o desactiva al usuario en todas las aplicaciones
+func (ctrl *AdminController) PutUserStatus(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	var req request.UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: el motivo admite hasta 255 caracteres"})
+		return
+	}
+
+	if err := ctrl.UserService.SetUserStatus(c.GetUint("user_id"), userID, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Usuario desactivado y sesiones revocadas"
+	if req.IsActive {
+		message = "Usuario reactivado"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// GetUserAttributes devuelve los atributos personalizados del usuario en la app y su esquema
+func (ctrl *AdminController) GetUserAttributes(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	view, err := ctrl.UserService.GetUserAttributes(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// PatchUserAttributes modifica los atributos personalizados del usuario en la app (null los borra)
+func (ctrl *AdminController) PatchUserAttributes(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	var changes map[string]interface{}
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Se espera un objeto JSON con los atributos"})
+		return
+	}
+
+	view, err := ctrl.UserService.UpdateUserAttributes(userID, c.Param("id"), changes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Atributos actualizados", "attributes": view})
+}
+
+// --- GRUPOS DE USUARIOS ---
+
+// parseGroupID lee el parámetro :group_id de la ruta
+func parseGroupID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("group_id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetGroups lista los grupos de usuarios
+func (ctrl *AdminController) GetGroups(c *gin.Context) {
+	groups, err := ctrl.GroupService.ListGroups()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error al cargar los grupos")
+		return
+	}
+
+	ctrl.renderAdmin(c, "groups.html", gin.H{
+		"Groups": groups,
+		"Breadcrumbs": []gin.H{
+			{"Label": "Grupos"},
+		},
+		"Title": "Grupos",
+	})
+}
+
+// GetGroupDetail muestra los miembros del grupo y los roles que concede en cada app
+func (ctrl *AdminController) GetGroupDetail(c *gin.Context) {
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		c.String(http.StatusBadRequest, "ID de grupo inválido")
+		return
+	}
+
+	detail, err := ctrl.GroupService.GetGroup(groupID)
+	if err != nil {
+		c.String(http.StatusNotFound, "Grupo no encontrado")
+		return
+	}
+
+	apps, err := ctrl.AppService.GetDashboardStats()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error al cargar las aplicaciones")
+		return
+	}
+
+	ctrl.renderAdmin(c, "group_detail.html", gin.H{
+		"Group": detail,
+		"Apps":  apps,
+		"Breadcrumbs": []gin.H{
+			{"Label": "Grupos", "URL": "/admin/groups"},
+			{"Label": detail.Group.Name},
+		},
+		"Title": "Grupo " + detail.Group.Name,
+	})
+}
+
+// PostGroup crea un grupo
+func (ctrl *AdminController) PostGroup(c *gin.Context) {
+	var req request.GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: el nombre admite hasta 100 caracteres y la descripción 255"})
+		return
+	}
+
+	group, err := ctrl.GroupService.CreateGroup(req)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Grupo creado", "id": group.ID})
+}
+
+// PutGroup renombra el grupo o cambia su descripción
+func (ctrl *AdminController) PutGroup(c *gin.Context) {
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de grupo inválido"})
+		return
+	}
+
+	var req request.GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: el nombre admite hasta 100 caracteres y la descripción 255"})
+		return
+	}
+
+	if err := ctrl.GroupService.UpdateGroup(groupID, req); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Grupo actualizado"})
+}
+
+// DeleteGroup elimina el grupo; sus miembros pierden los roles concedidos por él
+func (ctrl *AdminController) DeleteGroup(c *gin.Context) {
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de grupo inválido"})
+		return
+	}
+
+	if err := ctrl.GroupService.DeleteGroup(groupID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Grupo eliminado"})
+}
+
+// PostGroupMembers agrega usuarios al grupo en bloque (por ID y/o email)
+func (ctrl *AdminController) PostGroupMembers(c *gin.Context) {
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de grupo inválido"})
+		return
+	}
+
+	var req request.GroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	result, err := ctrl.GroupService.AddMembers(groupID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteGroupMember quita un usuario del grupo
+func (ctrl *AdminController) DeleteGroupMember(c *gin.Context) {
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de grupo inválido"})
+		return
+	}
+	userID, ok := parseUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	if err := ctrl.GroupService.RemoveMember(groupID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario quitado del grupo"})
+}
+
+// PostGroupRole concede un rol al grupo en una aplicación
+func (ctrl *AdminController) PostGroupRole(c *gin.Context) {
+	ctrl.groupRoleAction(c, ctrl.GroupService.GrantRole, "Rol concedido al grupo")
+}
+
+// DeleteGroupRole quita un rol concedido al grupo en una aplicación
+func (ctrl *AdminController) DeleteGroupRole(c *gin.Context) {
+	ctrl.groupRoleAction(c, ctrl.GroupService.RevokeRole, "Rol quitado del grupo")
+}
+
+// groupRoleAction resuelve el grupo de la URL y la app y el rol del body y ejecuta la acción
+func (ctrl *AdminController) groupRoleAction(c *gin.Context, action func(uint, request.GroupRoleRequest) error, message string) {
+	groupID, ok := parseGroupID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de grupo inválido"})
+		return
+	}
+
+	var req request.GroupRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Aplicación y rol requeridos"})
+		return
+	}
+
+	if err := action(groupID, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
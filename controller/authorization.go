@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Máximo de consultas por lote en /authorize.
+const authorizeBatchLimit = 100
+
+// AuthorizationController responde consultas de autorización de las aplicaciones cliente.
+type AuthorizationController struct {
+	AuthorizationService service.AuthorizationService
+}
+
+// PostAuthorize decide si un usuario puede hacer una acción sobre un recurso de la app que llama
+// (autenticada con X-App-Id / X-App-Secret). Con "checks" responde un lote de decisiones.
+func (c *AuthorizationController) PostAuthorize(ctx *gin.Context) {
+	app := ctx.MustGet("app").(model.Application)
+
+	var req request.AuthorizeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido"})
+		return
+	}
+
+	batch := len(req.Checks) > 0
+	checks := req.Checks
+	if !batch {
+		if req.Subject == "" && req.Action == "" && req.Resource == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "indica subject, action y resource, o un lote en checks"})
+			return
+		}
+		checks = []request.AuthorizeCheck{req.AuthorizeCheck}
+	}
+	if len(checks) > authorizeBatchLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("se admiten hasta %d consultas por lote", authorizeBatchLimit)})
+		return
+	}
+
+	decisions, err := c.AuthorizationService.Authorize(app.ID, checks)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if batch {
+		ctx.JSON(http.StatusOK, response.AuthorizeBatch{Decisions: decisions})
+		return
+	}
+	ctx.JSON(http.StatusOK, decisions[0])
+}
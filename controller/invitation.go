@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+	"peak-auth/request"
+	"peak-auth/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvitationController atiende la aceptación de invitaciones (links enviados por email).
+type InvitationController struct {
+	AppService service.ApplicationService
+}
+
+// GetAcceptInvitation muestra el formulario para aceptar la invitación
+func (c *InvitationController) GetAcceptInvitation(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	invitation, err := c.AppService.GetInvitation(token)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "accept_invitation.html", gin.H{
+		"token":      token,
+		"Invitation": invitation,
+	})
+}
+
+// PostAcceptInvitation crea la cuenta del invitado y le asigna los roles
+func (c *InvitationController) PostAcceptInvitation(ctx *gin.Context) {
+	var req request.AcceptInvitationRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.String(http.StatusBadRequest, "Datos inválidos: nombre y apellido admiten hasta 50 caracteres")
+		return
+	}
+
+	if err := c.AppService.AcceptInvitation(req); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "Invitación aceptada. Ya puedes iniciar sesión en tu aplicación.")
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"peak-auth/model"
 	"peak-auth/request"
@@ -28,8 +30,15 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+
 	response, err := c.UserService.Login(req, appID)
 	if err != nil {
+		if errors.Is(err, service.ErrChallengeRequired) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "challenge_required": true})
+			return
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -79,6 +88,19 @@ func (c *UserController) GetVerifyEmail(ctx *gin.Context) {
 	ctx.HTML(200, "verify_email.html", gin.H{})
 }
 
+// PostResendVerification reenvía el email de verificación. Responde siempre lo mismo
+// para no revelar si el email está registrado.
+func (c *UserController) PostResendVerification(ctx *gin.Context) {
+	var req request.ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "email (válido) es requerido"})
+		return
+	}
+
+	c.UserService.ResendVerificationByEmail(req.Email)
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Si el email corresponde a una cuenta pendiente de verificación, te enviamos un nuevo link"})
+}
+
 // GetResetPassword muestra el formulario de cambio de contraseña
 func (c *UserController) GetResetPassword(ctx *gin.Context) {
 	token := ctx.Query("token")
@@ -131,3 +153,286 @@ func (c *UserController) Refresh(ctx *gin.Context) {
 
 	ctx.JSON(200, resp)
 }
+
+// PostChangePassword cambia la contraseña del usuario autenticado por access token.
+func (c *UserController) PostChangePassword(ctx *gin.Context) {
+	var req request.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "current_password y new_password son requeridos"})
+		return
+	}
+
+	userID := ctx.GetUint("user_id")
+	appID := ctx.GetString("token_app_id")
+
+	if err := c.UserService.ChangePassword(userID, appID, req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Contraseña actualizada"})
+}
+
+// PostPasswordStrength devuelve el puntaje y las sugerencias para una contraseña candidata.
+// El header X-App-ID es opcional: si se envía, se informa el min_score de la PWD_POLICY.
+func (c *UserController) PostPasswordStrength(ctx *gin.Context) {
+	var req request.PasswordStrengthRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "password es requerido"})
+		return
+	}
+
+	resp, err := c.UserService.CheckPasswordStrength(ctx.GetHeader("X-App-ID"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// PostChallenge emite un desafío (proof-of-work o CAPTCHA) para el próximo login del email o username.
+func (c *UserController) PostChallenge(ctx *gin.Context) {
+	var req request.ChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "email o username es requerido"})
+		return
+	}
+
+	appID := ctx.GetHeader("X-App-ID")
+	if appID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "X-App-ID es requerido"})
+		return
+	}
+
+	resp, err := c.UserService.IssueLoginChallenge(appID, req.Identifier())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetRevokeSession muestra la confirmación para cerrar la sesión del aviso de nuevo dispositivo.
+// No cierra nada por sí mismo: los escáneres de links del correo hacen GET.
+func (c *UserController) GetRevokeSession(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "revoke_session.html", gin.H{
+		"token": token,
+	})
+}
+
+// PostRevokeSession cierra la sesión indicada en el link del aviso de nuevo dispositivo.
+func (c *UserController) PostRevokeSession(ctx *gin.Context) {
+	token := ctx.PostForm("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	if err := c.UserService.RevokeSession(token); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "Sesión cerrada. Te recomendamos cambiar tu contraseña.")
+}
+
+// GetMe devuelve el usuario autenticado con su perfil y sus roles en la app del token.
+func (c *UserController) GetMe(ctx *gin.Context) {
+	resp, err := c.UserService.GetMe(ctx.GetUint("user_id"), ctx.GetString("token_app_id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// PatchMe actualiza el perfil del usuario autenticado según la PROFILE_POLICY de la app.
+func (c *UserController) PatchMe(ctx *gin.Context) {
+	var req request.UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido"})
+		return
+	}
+
+	resp, err := c.UserService.UpdateMe(ctx.GetUint("user_id"), ctx.GetString("token_app_id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// PostChangeEmail inicia el cambio de email del usuario autenticado.
+func (c *UserController) PostChangeEmail(ctx *gin.Context) {
+	var req request.ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "new_email (válido) y current_password son requeridos"})
+		return
+	}
+
+	if err := c.UserService.RequestEmailChange(ctx.GetUint("user_id"), ctx.GetString("token_app_id"), req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Te enviamos un link de confirmación al nuevo email"})
+}
+
+// GetConfirmEmailChange muestra la confirmación del link enviado a la nueva dirección. El cambio
+// recién se aplica con el POST: los escáneres de links de los proveedores de correo abren los GET.
+func (c *UserController) GetConfirmEmailChange(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "confirm_link.html", gin.H{
+		"token":       token,
+		"title":       "Confirmar",
+		"highlight":   "Email",
+		"subtitle":    "Del cambio de email que solicitaste",
+		"description": "Confirmá que esta es tu nueva dirección. Vas a tener que volver a iniciar sesión con ella.",
+		"button":      "Confirmar el cambio",
+		"action":      "/api/v1/me/email/confirm",
+		"success":     "Email actualizado",
+	})
+}
+
+// PostConfirmEmailChange confirma el cambio de email indicado en el link de la nueva dirección.
+func (c *UserController) PostConfirmEmailChange(ctx *gin.Context) {
+	token := ctx.PostForm("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	if err := c.UserService.ConfirmEmailChange(token); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "Email actualizado. Volvé a iniciar sesión con tu nuevo email.")
+}
+
+// GetCancelEmailChange muestra la confirmación del link enviado a la dirección anterior.
+func (c *UserController) GetCancelEmailChange(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "confirm_link.html", gin.H{
+		"token":       token,
+		"title":       "Cancelar",
+		"highlight":   "Cambio",
+		"subtitle":    "Del cambio de email de tu cuenta",
+		"description": "Si no pediste cambiar tu email, cancelalo: tu cuenta sigue con esta dirección. Te recomendamos cambiar tu contraseña.",
+		"button":      "Cancelar el cambio",
+		"action":      "/api/v1/me/email/cancel",
+		"success":     "Cambio cancelado",
+	})
+}
+
+// PostCancelEmailChange cancela el cambio de email indicado en el link de la dirección anterior.
+func (c *UserController) PostCancelEmailChange(ctx *gin.Context) {
+	token := ctx.PostForm("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	if err := c.UserService.CancelEmailChange(token); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "Cambio de email cancelado")
+}
+
+// GetExportAccount descarga en JSON todos los datos guardados del usuario autenticado.
+func (c *UserController) GetExportAccount(ctx *gin.Context) {
+	export, err := c.UserService.ExportAccount(ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="peak-auth-export-%d.json"`, export.User.ID))
+	ctx.JSON(http.StatusOK, export)
+}
+
+// PostScheduleDeletion programa la baja de la cuenta del usuario autenticado.
+func (c *UserController) PostScheduleDeletion(ctx *gin.Context) {
+	var req request.DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "current_password es requerido"})
+		return
+	}
+
+	scheduledFor, err := c.UserService.ScheduleAccountDeletion(ctx.GetUint("user_id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Baja de cuenta programada", "scheduled_for": scheduledFor})
+}
+
+// DeleteScheduledDeletion cancela la baja programada del usuario autenticado.
+func (c *UserController) DeleteScheduledDeletion(ctx *gin.Context) {
+	if err := c.UserService.CancelAccountDeletion(ctx.GetUint("user_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Baja de cuenta cancelada"})
+}
+
+// GetCancelDeletion muestra la confirmación del link del email de baja. No cancela nada: los
+// escáneres de links de los proveedores de correo abren los GET por su cuenta.
+func (c *UserController) GetCancelDeletion(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "confirm_link.html", gin.H{
+		"token":       token,
+		"title":       "Cancelar",
+		"highlight":   "Baja",
+		"subtitle":    "De la baja de cuenta que solicitaste",
+		"description": "Si cambiaste de opinión, cancelá la baja: tu cuenta y tus datos se conservan como están.",
+		"button":      "Conservar mi cuenta",
+		"action":      "/api/v1/me/deletion/cancel",
+		"success":     "Baja cancelada",
+	})
+}
+
+// PostCancelDeletion cancela la baja programada indicada en el link del email.
+func (c *UserController) PostCancelDeletion(ctx *gin.Context) {
+	token := ctx.PostForm("token")
+	if token == "" {
+		ctx.String(http.StatusBadRequest, "Token requerido")
+		return
+	}
+
+	if err := c.UserService.CancelAccountDeletionByToken(token); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx.String(http.StatusOK, "Baja de cuenta cancelada")
+}
@@ -44,6 +44,8 @@ func AutoMigrate() {
 	if postgresqlDB == nil {
 		log.Fatal("La base de datos no está inicializada")
 	}
+	// Antes de AutoMigrate para que users.email nunca quede sin unicidad (ver migrateEmailIdentity)
+	migrateEmailIdentity(postgresqlDB)
 	postgresqlDB.AutoMigrate(
 		&model.Application{},
 		&model.Role{},
@@ -54,7 +56,27 @@ func AutoMigrate() {
 		&model.PasswordReset{},
 		&model.RefreshToken{},
 		&model.ApplicationRules{},
+		&model.UserLockout{},
+		&model.RateLimitBucket{},
+		&model.LoginAttempt{},
+		&model.UsedChallenge{},
+		&model.UserDevice{},
+		&model.EmailChange{},
+		&model.AccountDeletion{},
+		&model.AccessTokenRevocation{},
+		&model.Invitation{},
+		&model.UserAttributes{},
+		&model.ImpersonationLog{},
+		&model.Permission{},
+		&model.RolePermission{},
+		&model.RoleInheritance{},
+		&model.Group{},
+		&model.GroupMember{},
+		&model.GroupRole{},
 	)
+	migrateEmailIdentity(postgresqlDB)
+	migrateRoleScope(postgresqlDB)
+	seedSystemRoles(postgresqlDB)
 }
 
 func DisconnectDB() {
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"log"
+	"peak-auth/model"
+
+	"gorm.io/gorm"
+)
+
+// EmailDuplicate agrupa las cuentas activas (sin soft delete) que comparten el mismo email
+// sin distinguir mayúsculas ni espacios.
+type EmailDuplicate struct {
+	Email   string
+	Count   int
+	UserIDs string
+}
+
+// FindEmailDuplicates detecta los emails repetidos que impiden crear el índice único
+// insensible a mayúsculas.
+func FindEmailDuplicates(db *gorm.DB) ([]EmailDuplicate, error) {
+	var duplicates []EmailDuplicate
+	err := db.Raw(`
+		SELECT LOWER(TRIM(email)) AS email, COUNT(*) AS count, STRING_AGG(id::text, ', ' ORDER BY id) AS user_ids
+		FROM users
+		WHERE deleted_at IS NULL
+		GROUP BY LOWER(TRIM(email))
+		HAVING COUNT(*) > 1
+		ORDER BY 1`).Scan(&duplicates).Error
+	return duplicates, err
+}
+
+// migrateEmailIdentity reemplaza la unicidad exacta de users.email por un índice único sobre
+// LOWER(email) que ignora a los usuarios borrados, y normaliza los emails guardados.
+// Corre antes de AutoMigrate: el modelo ya no declara `unique`, y AutoMigrate borraría la
+// restricción exacta dejando el email sin unicidad si el índice todavía no existiera. La
+// restricción sólo se quita en la misma transacción que crea el índice y normaliza. Si hay duplicados
+// el servicio no arranca hasta que se resuelvan (fusionando o borrando las cuentas repetidas).
+func migrateEmailIdentity(db *gorm.DB) {
+	if !db.Migrator().HasTable(&model.User{}) {
+		return // Base nueva: se crea con AutoMigrate y el índice en la segunda pasada
+	}
+
+	duplicates, err := FindEmailDuplicates(db)
+	if err != nil {
+		log.Fatalf("Migración de emails: no se pudieron buscar duplicados: %v", err)
+	}
+	if len(duplicates) > 0 {
+		for _, d := range duplicates {
+			log.Printf("  - %s: %d cuentas (usuarios %s)", d.Email, d.Count, d.UserIDs)
+		}
+		log.Fatalf("Migración de emails: hay %d email(s) duplicados sin distinguir mayúsculas; resolvelos antes de iniciar el servicio", len(duplicates))
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Primero se quitan las restricciones exactas de versiones anteriores: un usuario borrado
+		// con "bob@x.com" y uno activo con "Bob@x.com" son válidos para ellas, pero chocarían al
+		// normalizar. Los borrados conservan su email y quedan fuera del índice parcial.
+		for _, constraint := range []string{"users_email_key", "uni_users_email"} {
+			if err := tx.Exec(`ALTER TABLE users DROP CONSTRAINT IF EXISTS ` + constraint).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email)) WHERE deleted_at IS NULL`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE users SET email = LOWER(TRIM(email)) WHERE deleted_at IS NULL AND email <> LOWER(TRIM(email))`).Error
+	})
+	if err != nil {
+		log.Fatalf("Migración de emails: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// migrateRoleScope reemplaza la unicidad global de roles.name por dos índices parciales:
+// uno para los roles globales y otro por aplicación, ignorando los roles borrados.
+func migrateRoleScope(db *gorm.DB) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_global_name ON roles (name) WHERE application_id IS NULL AND deleted_at IS NULL`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_app_name ON roles (application_id, name) WHERE application_id IS NOT NULL AND deleted_at IS NULL`).Error; err != nil {
+			return err
+		}
+		// Unicidad global de versiones anteriores (impide repetir el nombre en otra app)
+		if err := tx.Exec(`DROP INDEX IF EXISTS idx_roles_name`).Error; err != nil {
+			return err
+		}
+		for _, constraint := range []string{"roles_name_key", "uni_roles_name"} {
+			if err := tx.Exec(`ALTER TABLE roles DROP CONSTRAINT IF EXISTS ` + constraint).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Migración de roles por aplicación: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"log"
+	"peak-auth/utils"
+
+	"gorm.io/gorm"
+)
+
+// seedSystemRoles crea los roles globales que agregaron versiones posteriores al setup
+// (CreateRootUser sólo los crea en instalaciones nuevas). Antes del setup no hace nada
+// para no chocar con los roles que crea CreateRootUser.
+func seedSystemRoles(db *gorm.DB) {
+	for _, name := range []string{utils.RoleImpersonator} {
+		err := db.Exec(`
+			INSERT INTO roles (name, is_default, created_at, updated_at)
+			SELECT ?, true, NOW(), NOW()
+			WHERE EXISTS (SELECT 1 FROM roles WHERE name = 'ROOT' AND application_id IS NULL AND deleted_at IS NULL)
+			AND NOT EXISTS (SELECT 1 FROM roles WHERE name = ? AND application_id IS NULL AND deleted_at IS NULL)`,
+			name, name).Error
+		if err != nil {
+			log.Printf("Alta del rol %s: %v", name, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"peak-auth/app"
@@ -46,6 +47,18 @@ func main() {
 	// 5) Gin router
 	router := gin.New()
 
+	// ClientIP() sólo confía en X-Forwarded-For si la conexión viene de un proxy listado en
+	// TRUSTED_PROXIES; sin la variable no se confía en ninguno (los límites por IP dependen de esto)
+	var trustedProxies []string
+	if raw := os.Getenv("TRUSTED_PROXIES"); raw != "" {
+		for _, proxy := range strings.Split(raw, ",") {
+			trustedProxies = append(trustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("TRUSTED_PROXIES inválido: %v", err)
+	}
+
 	// Registrar funciones globales para templates
 	funcMap := template.FuncMap{
 		"now": func() time.Time {
@@ -89,6 +102,12 @@ func main() {
 
 	SetupRoutes(router, appInstance)
 
+	// Barrido periódico de las bajas de cuenta vencidas y de los intentos de login fuera de la retención
+	go appInstance.UserService.StartDeletionSweeper(time.Hour)
+
+	// Barrido de los roles temporales vencidos (ya no cuentan desde que vencen; esto los elimina)
+	go appInstance.AppService.StartGrantExpirySweeper(15 * time.Minute)
+
 	appInstance.SetupService.InitializeSystem(port)
 
 	if err := router.Run(":" + port); err != nil {
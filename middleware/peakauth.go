@@ -49,6 +49,22 @@ func AuthMiddleware(manager *auth.JWTManager) gin.HandlerFunc {
 		c.Set("user_id", userID)
 		c.Set("user_email", jsonToken.Username)
 		c.Set("user_roles", jsonToken.Roles)
+		c.Set("token_app_id", jsonToken.AppID)
+		if jsonToken.Act != nil {
+			c.Set("actor_id", jsonToken.Act.Subject)
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation bloquea las acciones sensibles de la cuenta (contraseña, email, baja)
+// cuando el token fue emitido por suplantación (lleva el claim "act").
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("actor_id"); impersonated {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "acción no permitida durante una suplantación"})
+			return
+		}
 		c.Next()
 	}
 }
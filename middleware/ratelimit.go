@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"peak-auth/model"
+	"peak-auth/ratelimit"
+	"peak-auth/repository"
+	"peak-auth/service"
+	"peak-auth/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteLimits son los límites por defecto de una ruta. Un límite nil no se aplica.
+type RouteLimits struct {
+	IP    *ratelimit.Limit
+	Email *ratelimit.Limit
+	App   *ratelimit.Limit
+	User  *ratelimit.Limit
+}
+
+// Tamaño máximo del body que se lee para extraer el email.
+const maxRateLimitBodyBytes = 1 << 20
+
+// RateLimitMiddleware aplica token buckets por IP, email (del body JSON), aplicación y usuario.
+// El bucket por aplicación sólo se aplica si AppAuthMiddleware ya validó el secreto: con el
+// X-App-Id sin autenticar cualquiera podría agotar el bucket de la app ajena. El bucket por
+// usuario sólo se aplica después de AuthMiddleware (rutas con access token).
+// Los límites de la ruta pueden sobrescribirse por app con la regla RATE_LIMIT_POLICY.
+// Al exceder un límite responde 429 con el header Retry-After.
+func RateLimitMiddleware(store ratelimit.Store, appRepo repository.ApplicationRepository, ruleService service.ApplicationRuleService, route string, defaults RouteLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limits := defaults
+
+		var authenticatedApp string
+		if value, ok := c.Get("app"); ok {
+			app := value.(model.Application)
+			authenticatedApp = app.AppID
+			limits = applyRateLimitPolicy(ruleService, app.ID, route, limits)
+		} else if appID := c.GetString("token_app_id"); appID != "" {
+			if app, err := appRepo.FindByAppID(appID); err == nil {
+				limits = applyRateLimitPolicy(ruleService, app.ID, route, limits)
+			}
+		} else if appID := c.GetHeader("X-App-Id"); appID != "" {
+			if app, err := appRepo.FindByAppID(appID); err == nil {
+				limits = applyRateLimitPolicy(ruleService, app.ID, route, limits)
+			}
+		}
+
+		email, err := emailFromBody(c)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "el cuerpo de la solicitud es demasiado grande"})
+			return
+		}
+
+		checks := []struct {
+			dimension string
+			value     string
+			limit     *ratelimit.Limit
+		}{
+			{"ip", c.ClientIP(), limits.IP},
+			{"email", emailKey(email), limits.Email},
+			{"app", authenticatedApp, limits.App},
+			{"user", userKey(c), limits.User},
+		}
+
+		var retryAfter time.Duration
+		for _, check := range checks {
+			if check.limit == nil || check.value == "" {
+				continue
+			}
+			key := route + ":" + check.dimension + ":" + check.value
+			result, err := store.Take(key, *check.limit)
+			if err != nil {
+				// Ante una falla del backend preferimos no cortar el servicio
+				log.Printf("rate limiter: error consultando %s: %v", key, err)
+				continue
+			}
+			if !result.Allowed && result.RetryAfter > retryAfter {
+				retryAfter = result.RetryAfter
+			}
+		}
+
+		if retryAfter > 0 {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "demasiadas solicitudes, intentá nuevamente más tarde",
+				"retry_after": seconds,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// applyRateLimitPolicy sobrescribe los límites de la ruta con los de la regla de la app.
+func applyRateLimitPolicy(ruleService service.ApplicationRuleService, appID uint, route string, limits RouteLimits) RouteLimits {
+	rules, err := ruleService.FindRulesByAppID(appID)
+	if err != nil {
+		return limits
+	}
+	for _, r := range rules {
+		if r.Code != "RATE_LIMIT_POLICY" {
+			continue
+		}
+		policy, err := utils.ParseRateLimitPolicy(r.Value)
+		if err != nil {
+			continue
+		}
+		override, ok := policy.Routes[route]
+		if !ok {
+			continue
+		}
+		if override.IP != nil {
+			limits.IP = limitFromSpec(*override.IP)
+		}
+		if override.Email != nil {
+			limits.Email = limitFromSpec(*override.Email)
+		}
+		if override.App != nil {
+			limits.App = limitFromSpec(*override.App)
+		}
+		if override.User != nil {
+			limits.User = limitFromSpec(*override.User)
+		}
+	}
+	return limits
+}
+
+func limitFromSpec(spec utils.RateLimitSpec) *ratelimit.Limit {
+	if spec.Requests <= 0 {
+		return nil
+	}
+	window := time.Duration(spec.WindowSeconds) * time.Second
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &ratelimit.Limit{Requests: spec.Requests, Window: window, Burst: spec.Burst}
+}
+
+// emailFromBody lee el campo "email" (o "username" si no viene) del body JSON sin consumirlo
+// para el handler: el límite por cuenta aplica a cualquiera de los dos identificadores.
+// userKey identifica al usuario del access token; vacío si la ruta no pasó por AuthMiddleware.
+func userKey(c *gin.Context) string {
+	if userID := c.GetUint("user_id"); userID != 0 {
+		return strconv.FormatUint(uint64(userID), 10)
+	}
+	return ""
+}
+
+// emailKey resume el email (o username) en un hash de largo fijo: viene del body sin límite de
+// largo ni de contenido, y si la clave no entrara en el store el limitador fallaría abierto.
+func emailKey(email string) string {
+	if email == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sólo lee hasta maxRateLimitBodyBytes; un body más grande devuelve *http.MaxBytesError.
+func emailFromBody(c *gin.Context) (string, error) {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return "", nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRateLimitBodyBytes))
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+
+	var payload struct {
+		Email    string `json:"email"`
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", nil
+	}
+	if payload.Email == "" {
+		return strings.ToLower(strings.TrimSpace(payload.Username)), nil
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email)), nil
+}
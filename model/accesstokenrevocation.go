@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// AccessTokenRevocation invalida los access tokens (JWT) emitidos a un usuario antes de
+// RevokedBefore. Como los JWT no se persisten, la deny-list se lleva por usuario.
+type AccessTokenRevocation struct {
+	UserID        uint      `gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `gorm:"not null"`
+}
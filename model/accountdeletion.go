@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AccountDeletion es una baja de cuenta solicitada por el usuario. Durante el período de
+// gracia puede cancelarse; al vencer, el barrido anonimiza o elimina los datos.
+type AccountDeletion struct {
+	gorm.Model
+	UserID          uint      `gorm:"index;not null"`
+	ScheduledFor    time.Time `gorm:"index"`
+	CancelTokenHash []byte    `gorm:"index"`
+	CanceledAt      *time.Time
+	CompletedAt     *time.Time
+	Mode            string `gorm:"type:varchar(20)"` // "anonymize" o "hard", registrado al completarse
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailChange es una solicitud de cambio de email pendiente de confirmar desde la nueva
+// dirección. La dirección anterior recibe un token para cancelarla.
+type EmailChange struct {
+	gorm.Model
+	UserID          uint `gorm:"index"`
+	ApplicationID   uint
+	User            User   `gorm:"foreignKey:UserID"`
+	OldEmail        string `gorm:"type:varchar(100);not null"`
+	NewEmail        string `gorm:"type:varchar(100);not null"`
+	TokenHash       []byte `gorm:"index"`
+	CancelTokenHash []byte `gorm:"index"`
+	ExpiresAt       time.Time
+	UsedAt          *time.Time
+	CanceledAt      *time.Time
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Group es un conjunto con nombre de usuarios. Los roles que se le conceden en cada aplicación
+// se suman a los que sus miembros tienen asignados directamente.
+type Group struct {
+	gorm.Model
+	Name        string `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:varchar(255)" json:"description"`
+}
+
+// GroupMember vincula un usuario a un grupo.
+type GroupMember struct {
+	GroupID   uint `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time
+}
+
+// GroupRole concede un rol a todos los miembros del grupo dentro de una aplicación.
+type GroupRole struct {
+	GroupID       uint `gorm:"primaryKey;autoIncrement:false"`
+	ApplicationID uint `gorm:"primaryKey;autoIncrement:false;index"`
+	RoleID        uint `gorm:"primaryKey;autoIncrement:false;index"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImpersonationLog registra cada sesión de suplantación iniciada desde la consola:
+// quién actuó, sobre qué usuario y aplicación, con qué motivo y hasta cuándo vale el token.
+type ImpersonationLog struct {
+	gorm.Model
+	ActorID       uint      `gorm:"index;not null" json:"actor_id"`
+	UserID        uint      `gorm:"index;not null" json:"user_id"`
+	ApplicationID uint      `gorm:"index;not null" json:"application_id"`
+	Reason        string    `gorm:"type:varchar(255);not null" json:"reason"`
+	IP            string    `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent     string    `gorm:"type:varchar(255)" json:"user_agent"`
+	ExpiresAt     time.Time `gorm:"not null" json:"expires_at"`
+}
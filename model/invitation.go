@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Estados de una invitación. El vencimiento se deriva de ExpiresAt.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+)
+
+// Invitation invita a un email todavía no registrado a una aplicación con uno o más
+// roles. La cuenta recién se crea cuando el invitado acepta y elige su contraseña.
+type Invitation struct {
+	gorm.Model
+	ApplicationID  uint        `gorm:"not null;index" json:"application_id"`
+	Application    Application `gorm:"foreignKey:ApplicationID" json:"-"`
+	Email          string      `gorm:"type:varchar(100);not null;index" json:"email"`
+	Roles          []Role      `gorm:"many2many:invitation_roles" json:"roles"`
+	InvitedByID    *uint       `json:"invited_by_id"`
+	InvitedBy      *User       `gorm:"foreignKey:InvitedByID" json:"-"`
+	Status         string      `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	TokenHash      []byte      `gorm:"index" json:"-"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+	SendCount      int         `gorm:"not null;default:0" json:"send_count"`
+	LastSentAt     time.Time   `json:"last_sent_at"`
+	AcceptedAt     *time.Time  `json:"accepted_at"`
+	AcceptedUserID *uint       `json:"accepted_user_id"`
+	RevokedAt      *time.Time  `json:"revoked_at"`
+}
+
+// IsExpired indica si la invitación pendiente ya no puede aceptarse.
+func (i Invitation) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt registra cada intento de login (exitoso o no) para detectar abuso por IP
+// y para auditar la actividad de los usuarios.
+type LoginAttempt struct {
+	gorm.Model
+	UserID        *uint  `gorm:"index" json:"user_id"`
+	ApplicationID uint   `gorm:"index" json:"application_id"`
+	Email         string `gorm:"type:varchar(100)" json:"email"`
+	IP            string `gorm:"type:varchar(64);index" json:"ip"`
+	UserAgent     string `gorm:"type:varchar(255)" json:"user_agent"`
+	Success       bool   `json:"success"`
+	Reason        string `gorm:"type:varchar(50)" json:"reason"`
+}
+
+// UsedChallenge registra los desafíos de login ya canjeados para que un token resuelto no
+// pueda reutilizarse mientras siga vigente. TokenHash es el SHA-256 del token del desafío.
+type UsedChallenge struct {
+	TokenHash string    `gorm:"type:char(64);primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "gorm.io/gorm"
+
+// Permission es una entrada del catálogo de permisos de una aplicación (p.ej. "orders:read").
+// El código es único dentro de la app y viaja tal cual en el claim "permissions" del JWT.
+type Permission struct {
+	gorm.Model
+	ApplicationID uint   `gorm:"not null;uniqueIndex:idx_app_permission_code" json:"application_id"`
+	Code          string `gorm:"type:varchar(100);not null;uniqueIndex:idx_app_permission_code" json:"code"`
+	Description   string `gorm:"type:varchar(255)" json:"description"`
+}
+
+// RolePermission concede un permiso (de una app concreta) a un rol.
+type RolePermission struct {
+	RoleID       uint `gorm:"primaryKey;autoIncrement:false"`
+	PermissionID uint `gorm:"primaryKey;autoIncrement:false;index"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// RateLimitBucket persiste un token bucket del rate limiter cuando se usa el backend Postgres.
+// ExpiresAt es el momento en que el bucket ya estaría lleno otra vez: a partir de ahí la fila
+// equivale a un bucket nuevo y el barrido puede borrarla.
+type RateLimitBucket struct {
+	Key        string    `gorm:"type:varchar(255);primaryKey"`
+	Tokens     float64   `gorm:"not null"`
+	LastRefill time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"index"`
+}
@@ -12,4 +12,7 @@ type RefreshToken struct {
 	ApplicationID uint
 	Token         string `gorm:"uniqueIndex;not null"`
 	ExpiresAt     time.Time
+	// Sesiones iniciadas desde un dispositivo nuevo: se pueden revocar desde el link del email
+	UserDeviceID    *uint
+	RevokeTokenHash []byte `gorm:"index"`
 }
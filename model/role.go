@@ -2,8 +2,16 @@ package model
 
 import "gorm.io/gorm"
 
+// Role es global (ApplicationID nil: ROOT, ADMIN, USER… valen en todas las apps) o propio
+// de una aplicación. El nombre es único entre los globales y dentro de cada app.
 type Role struct {
 	gorm.Model
-	Name      string `gorm:"type:varchar(100);uniqueIndex;not null"`
-	IsDefault bool   `gorm:"default:false"`
+	Name          string `gorm:"type:varchar(100);not null"`
+	IsDefault     bool   `gorm:"default:false"`
+	ApplicationID *uint  `gorm:"index"`
+}
+
+// IsGlobal indica si el rol es del sistema y no de una aplicación concreta.
+func (r Role) IsGlobal() bool {
+	return r.ApplicationID == nil
 }
@@ -0,0 +1,10 @@
+package model
+
+// RoleInheritance indica que quien tiene RoleID también tiene ParentID (p.ej. MANAGER hereda USER).
+// Un rol propio de una app sólo hereda roles globales o de su misma app.
+type RoleInheritance struct {
+	RoleID   uint `gorm:"primaryKey;autoIncrement:false"`
+	ParentID uint `gorm:"primaryKey;autoIncrement:false;index"`
+	Role     Role `gorm:"foreignKey:RoleID"`
+	Parent   Role `gorm:"foreignKey:ParentID"`
+}
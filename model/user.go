@@ -8,11 +8,16 @@ import (
 
 type User struct {
 	gorm.Model
-	Password     string    `gorm:"type:varchar(255);not null" json:"-"`
-	Email        string    `gorm:"type:varchar(100);not null;unique" json:"email"`
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	IsVerified   bool      `gorm:"default:false" json:"is_verified"`
-	FailedLogins uint      `gorm:"default:0" json:"failed_logins"`
-	LastLogin    time.Time `json:"last_login"`
-	Profile      Profile   `gorm:"foreignKey:UserID"`
+	Password   string    `gorm:"type:varchar(255);not null" json:"-"`
+	Email      string    `gorm:"type:varchar(100);not null" json:"email"`      // Normalizado; único sin mayúsculas (idx_users_email_lower)
+	Username   *string   `gorm:"type:varchar(30);uniqueIndex" json:"username"` // Normalizado (minúsculas); opcional
+	IsActive   bool      `gorm:"default:true" json:"is_active"`
+	IsVerified bool      `gorm:"default:false" json:"is_verified"`
+	LastLogin  time.Time `json:"last_login"`
+	Profile    Profile   `gorm:"foreignKey:UserID"`
+
+	// Último cambio de estado (activación/desactivación) hecho desde la consola
+	StatusReason    string     `gorm:"type:varchar(255)" json:"-"`
+	StatusChangedAt *time.Time `json:"-"`
+	StatusChangedBy *uint      `json:"-"`
 }
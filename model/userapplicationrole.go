@@ -1,12 +1,21 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// UserApplicationRole vincula un usuario con un rol dentro de una aplicación. StartsAt y
+// ExpiresAt son opcionales y acotan la vigencia (accesos temporales): fuera de ese rango
+// el rol no cuenta y, una vez vencido, el barrido lo elimina.
 type UserApplicationRole struct {
 	gorm.Model
-	UserID        uint        `gorm:"not null;index"`
-	ApplicationID uint        `gorm:"not null;index"`
-	RoleID        uint        `gorm:"not null;index"`
+	UserID        uint `gorm:"not null;index"`
+	ApplicationID uint `gorm:"not null;index"`
+	RoleID        uint `gorm:"not null;index"`
+	StartsAt      *time.Time
+	ExpiresAt     *time.Time  `gorm:"index"`
 	User          User        `gorm:"foreignKey:UserID"`
 	Application   Application `gorm:"foreignKey:ApplicationID"`
 	Role          Role        `gorm:"foreignKey:RoleID"`
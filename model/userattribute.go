@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// UserAttributes guarda los atributos personalizados de un usuario en una aplicación.
+// Data es un objeto JSON validado contra la regla ATTRIBUTE_SCHEMA de la app.
+type UserAttributes struct {
+	UserID        uint   `gorm:"primaryKey;autoIncrement:false"`
+	ApplicationID uint   `gorm:"primaryKey;autoIncrement:false;index"`
+	Data          []byte `gorm:"type:jsonb;not null"`
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserDevice es un dispositivo conocido del usuario, identificado por la huella
+// del user agent y el prefijo de la IP desde la que inició sesión.
+type UserDevice struct {
+	gorm.Model
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_user_devices_user_fp" json:"user_id"`
+	Fingerprint string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_devices_user_fp" json:"fingerprint"`
+	UserAgent   string    `gorm:"type:varchar(255)" json:"user_agent"`
+	IPPrefix    string    `gorm:"type:varchar(64)" json:"ip_prefix"`
+	LastIP      string    `gorm:"type:varchar(64)" json:"last_ip"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
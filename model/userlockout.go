@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserLockout registra los intentos fallidos de un usuario en una aplicación puntual.
+// LockCount guarda cuántos bloqueos consecutivos sufrió para aplicar backoff exponencial.
+type UserLockout struct {
+	gorm.Model
+	UserID        uint       `gorm:"not null;uniqueIndex:idx_user_lockouts_user_app"`
+	ApplicationID uint       `gorm:"not null;uniqueIndex:idx_user_lockouts_user_app"`
+	FailedLogins  uint       `gorm:"default:0" json:"failed_logins"`
+	LockCount     uint       `gorm:"default:0" json:"lock_count"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	LastFailedAt  *time.Time `json:"last_failed_at"`
+}
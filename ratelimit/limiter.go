@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Limit define un token bucket: Burst tokens de capacidad que se recargan a razón de
+// Requests tokens cada Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+	Burst    int
+}
+
+// PerMinute construye un límite de n solicitudes por minuto con ráfaga n.
+func PerMinute(n int) *Limit {
+	return &Limit{Requests: n, Window: time.Minute, Burst: n}
+}
+
+// PerHour construye un límite de n solicitudes por hora con ráfaga n.
+func PerHour(n int) *Limit {
+	return &Limit{Requests: n, Window: time.Hour, Burst: n}
+}
+
+// Result es el resultado de consumir un token del bucket.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store es el backend donde se guardan los buckets. Implementaciones: memoria y Postgres.
+type Store interface {
+	Take(key string, limit Limit) (Result, error)
+}
+
+// NewStoreFromEnv elige el backend según RATE_LIMIT_BACKEND ("memory" por defecto o "postgres").
+// Con varias instancias detrás de un balanceador conviene Postgres para compartir contadores.
+func NewStoreFromEnv(db *gorm.DB) Store {
+	if os.Getenv("RATE_LIMIT_BACKEND") == "postgres" {
+		log.Println("🚦 Rate limiter inicializado con backend POSTGRES")
+		return NewPostgresStore(db)
+	}
+	log.Println("🚦 Rate limiter inicializado con backend MEMORY")
+	return NewMemoryStore()
+}
+
+func (l Limit) ratePerSecond() float64 {
+	if l.Window <= 0 {
+		return 0
+	}
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// refillTime es lo que tarda un bucket vacío en volver a llenarse.
+func (l Limit) refillTime() time.Duration {
+	rate := l.ratePerSecond()
+	if rate <= 0 {
+		return l.Window
+	}
+	return time.Duration(l.capacity() / rate * float64(time.Second))
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// consume recarga el bucket según el tiempo transcurrido e intenta descontar un token.
+// Devuelve los tokens restantes y el resultado.
+func consume(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	capacity := limit.capacity()
+	rate := limit.ratePerSecond()
+
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+
+	retry := time.Duration(math.MaxInt64)
+	if rate > 0 {
+		retry = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return tokens, Result{Allowed: false, RetryAfter: retry}
+}
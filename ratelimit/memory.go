@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	window time.Duration
+}
+
+// MemoryStore guarda los buckets en memoria del proceso. Sirve para una única instancia.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+// NewMemoryStore construye un store en memoria.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take consume un token del bucket identificado por key.
+func (s *MemoryStore) Take(key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, exists := s.buckets[key]
+	if !exists {
+		b = &bucket{tokens: limit.capacity(), last: now, window: limit.Window}
+		s.buckets[key] = b
+	}
+
+	tokens, result := consume(b.tokens, b.last, now, limit)
+	b.tokens = tokens
+	b.last = now
+
+	s.calls++
+	if s.calls%1000 == 0 {
+		s.evictIdle(now)
+	}
+	return result, nil
+}
+
+// evictIdle elimina buckets que ya se recargaron por completo para no crecer sin límite.
+func (s *MemoryStore) evictIdle(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.window {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"log"
+	"peak-auth/model"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresStore guarda los buckets en la tabla rate_limit_buckets para que varias
+// instancias compartan los contadores.
+type PostgresStore struct {
+	db    *gorm.DB
+	calls atomic.Int64
+}
+
+// Cada cuántas llamadas a Take se borran los buckets vencidos.
+const postgresSweepEvery = 1000
+
+// NewPostgresStore construye un store respaldado por Postgres.
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take consume un token bloqueando la fila del bucket durante la transacción.
+func (s *PostgresStore) Take(key string, limit Limit) (Result, error) {
+	var result Result
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Crear el bucket lleno si no existe (sin pisar uno concurrente)
+		seed := model.RateLimitBucket{Key: key, Tokens: limit.capacity(), LastRefill: now, ExpiresAt: now.Add(limit.refillTime())}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seed).Error; err != nil {
+			return err
+		}
+
+		var b model.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&b).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = consume(b.Tokens, b.LastRefill, now, limit)
+		return tx.Model(&model.RateLimitBucket{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"tokens":      tokens,
+			"last_refill": now,
+			"expires_at":  now.Add(limit.refillTime()),
+		}).Error
+	})
+
+	if s.calls.Add(1)%postgresSweepEvery == 0 {
+		go s.deleteExpired(time.Now())
+	}
+	return result, err
+}
+
+// deleteExpired borra los buckets que ya se habrían recargado por completo. Las filas previas
+// a expires_at se borran cuando llevan un día sin uso.
+func (s *PostgresStore) deleteExpired(now time.Time) {
+	err := s.db.Where("expires_at < ? OR (expires_at IS NULL AND last_refill < ?)", now, now.Add(-24*time.Hour)).
+		Delete(&model.RateLimitBucket{}).Error
+	if err != nil {
+		log.Printf("rate limiter: error borrando buckets vencidos: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+	"peak-auth/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccessTokenRevocationRepository interface {
+	RevokedBefore(userID uint) (time.Time, bool, error)
+}
+
+type accessTokenRevocationRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessTokenRevocationRepository construye la deny-list de access tokens.
+func NewAccessTokenRevocationRepository(db *gorm.DB) AccessTokenRevocationRepository {
+	return &accessTokenRevocationRepository{db: db}
+}
+
+// RevokedBefore devuelve el instante antes del cual los tokens del usuario no son válidos.
+func (r *accessTokenRevocationRepository) RevokedBefore(userID uint) (time.Time, bool, error) {
+	var revocation model.AccessTokenRevocation
+	err := r.db.Where("user_id = ?", userID).First(&revocation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return revocation.RevokedBefore, true, nil
+}
+
+// revokeAccessTokens registra (o adelanta) la revocación de los access tokens del usuario.
+func revokeAccessTokens(tx *gorm.DB, userID uint, before time.Time) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before"}),
+	}).Create(&model.AccessTokenRevocation{UserID: userID, RevokedBefore: before}).Error
+}
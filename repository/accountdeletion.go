@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"peak-auth/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccountDeletionRepository interface {
+	Create(deletion *model.AccountDeletion) error
+	FindPendingByUser(userID uint) (*model.AccountDeletion, error)
+	FindPendingByCancelToken(plainToken string) (*model.AccountDeletion, error)
+	Cancel(id uint) error
+	FindDue(now time.Time) ([]model.AccountDeletion, error)
+	Anonymize(deletion *model.AccountDeletion) error
+	HardDelete(deletion *model.AccountDeletion) error
+}
+
+type accountDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountDeletionRepository construye el repositorio de bajas de cuenta.
+func NewAccountDeletionRepository(db *gorm.DB) AccountDeletionRepository {
+	return &accountDeletionRepository{db: db}
+}
+
+func (r *accountDeletionRepository) Create(deletion *model.AccountDeletion) error {
+	return r.db.Create(deletion).Error
+}
+
+func (r *accountDeletionRepository) pending() *gorm.DB {
+	return r.db.Where("canceled_at IS NULL AND completed_at IS NULL")
+}
+
+// FindPendingByUser devuelve la baja programada del usuario, si tiene una.
+func (r *accountDeletionRepository) FindPendingByUser(userID uint) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	if err := r.pending().Where("user_id = ?", userID).First(&deletion).Error; err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// FindPendingByCancelToken busca la baja programada por el token del email de aviso.
+func (r *accountDeletionRepository) FindPendingByCancelToken(plainToken string) (*model.AccountDeletion, error) {
+	hash := sha256.Sum256([]byte(plainToken))
+	var deletion model.AccountDeletion
+	if err := r.pending().Where("cancel_token_hash = ?", hash[:]).First(&deletion).Error; err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r *accountDeletionRepository) Cancel(id uint) error {
+	return r.db.Model(&model.AccountDeletion{}).Where("id = ?", id).Update("canceled_at", time.Now()).Error
+}
+
+// FindDue devuelve las bajas cuyo período de gracia ya venció.
+func (r *accountDeletionRepository) FindDue(now time.Time) ([]model.AccountDeletion, error) {
+	var deletions []model.AccountDeletion
+	err := r.pending().Where("scheduled_for <= ?", now).Find(&deletions).Error
+	return deletions, err
+}
+
+// deleteCredentials borra (físicamente) las sesiones, tokens, roles, grupos, dispositivos, bloqueos y atributos
+// del usuario, revoca sus access tokens y olvida sus invitaciones.
+func deleteCredentials(tx *gorm.DB, userID uint) error {
+	for _, m := range []any{
+		&model.UserApplicationRole{},
+		&model.GroupMember{},
+		&model.RefreshToken{},
+		&model.EmailVerification{},
+		&model.PasswordReset{},
+		&model.EmailChange{},
+		&model.UserDevice{},
+		&model.UserLockout{},
+		&model.Profile{},
+		&model.UserAttributes{},
+	} {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	if err := revokeAccessTokens(tx, userID, time.Now()); err != nil {
+		return err
+	}
+	return deleteInvitations(tx, userID)
+}
+
+// deleteInvitations borra las invitaciones dirigidas al email del usuario (o aceptadas por él) y
+// desvincula las que envió, que quedan sin autor (invited_by_id tiene FK a users).
+func deleteInvitations(tx *gorm.DB, userID uint) error {
+	var user model.User
+	if err := tx.Unscoped().Select("email").First(&user, userID).Error; err != nil {
+		return err
+	}
+	received := tx.Unscoped().Model(&model.Invitation{}).Select("id").
+		Where("LOWER(email) = LOWER(?) OR accepted_user_id = ?", user.Email, userID)
+	if err := tx.Exec(`DELETE FROM invitation_roles WHERE invitation_id IN (?)`, received).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("LOWER(email) = LOWER(?) OR accepted_user_id = ?", user.Email, userID).
+		Delete(&model.Invitation{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&model.Invitation{}).Where("invited_by_id = ?", userID).
+		Update("invited_by_id", nil).Error
+}
+
+func markCompleted(tx *gorm.DB, deletion *model.AccountDeletion, mode string) error {
+	return tx.Model(&model.AccountDeletion{}).Where("id = ?", deletion.ID).
+		Updates(map[string]any{"completed_at": time.Now(), "mode": mode, "cancel_token_hash": nil}).Error
+}
+
+// Anonymize elimina los datos asociados y deja el usuario como un registro sin datos personales,
+// desactivado y sin contraseña utilizable. Los intentos de login se conservan sin email, IP ni dispositivo.
+func (r *accountDeletionRepository) Anonymize(deletion *model.AccountDeletion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteCredentials(tx, deletion.UserID); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.LoginAttempt{}).Where("user_id = ?", deletion.UserID).
+			Updates(map[string]any{"email": "", "ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		// Las suplantaciones sufridas se conservan para auditoría, sin el motivo (puede describir al usuario)
+		if err := tx.Model(&model.ImpersonationLog{}).Where("user_id = ?", deletion.UserID).
+			Update("reason", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", deletion.UserID).Updates(map[string]any{
+			"email":       fmt.Sprintf("deleted-%d@deleted.invalid", deletion.UserID),
+			"username":    nil,
+			"password":    "",
+			"is_active":   false,
+			"is_verified": false,
+			"deleted_at":  time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return markCompleted(tx, deletion, "anonymize")
+	})
+}
+
+// HardDelete elimina físicamente al usuario y todo lo asociado, incluidos sus intentos de login y
+// las suplantaciones que sufrió.
+func (r *accountDeletionRepository) HardDelete(deletion *model.AccountDeletion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteCredentials(tx, deletion.UserID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", deletion.UserID).Delete(&model.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", deletion.UserID).Delete(&model.ImpersonationLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&model.User{}, deletion.UserID).Error; err != nil {
+			return err
+		}
+		return markCompleted(tx, deletion, "hard")
+	})
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"peak-auth/model"
+	"peak-auth/response"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccountExportRepository interface {
+	Export(userID uint) (response.AccountExport, error)
+}
+
+type accountExportRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountExportRepository construye el repositorio que reúne los datos de un usuario.
+func NewAccountExportRepository(db *gorm.DB) AccountExportRepository {
+	return &accountExportRepository{db: db}
+}
+
+// Export reúne todo lo que se guarda del usuario, sin secretos (hashes de contraseña o tokens).
+func (r *accountExportRepository) Export(userID uint) (response.AccountExport, error) {
+	export := response.AccountExport{ExportedAt: time.Now()}
+
+	var user model.User
+	if err := r.db.Preload("Profile").First(&user, userID).Error; err != nil {
+		return export, err
+	}
+	export.User = response.AccountExportUser{
+		ID:         user.ID,
+		Email:      user.Email,
+		IsActive:   user.IsActive,
+		IsVerified: user.IsVerified,
+		LastLogin:  user.LastLogin,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+	}
+	export.Profile = response.AccountExportProfile{
+		FirstName: user.Profile.FirstName,
+		LastName:  user.Profile.LastName,
+		BirthDate: user.Profile.BirthDate,
+		AvatarURL: user.Profile.AvatarURL,
+		UpdatedAt: user.Profile.UpdatedAt,
+	}
+
+	queries := []struct {
+		dest  any
+		query *gorm.DB
+	}{
+		{&export.ApplicationRoles, r.db.Table("user_application_roles uar").
+			Select("apps.app_id, apps.name AS app_name, roles.name AS role, uar.created_at, uar.deleted_at").
+			Joins("JOIN applications apps ON apps.id = uar.application_id").
+			Joins("JOIN roles ON roles.id = uar.role_id").
+			Where("uar.user_id = ?", userID).Order("uar.created_at")},
+		{&export.Sessions, r.db.Table("refresh_tokens rt").
+			Select("apps.app_id, rt.created_at, rt.expires_at").
+			Joins("LEFT JOIN applications apps ON apps.id = rt.application_id").
+			Where("rt.user_id = ? AND rt.deleted_at IS NULL", userID).Order("rt.created_at")},
+		{&export.Devices, r.db.Table("user_devices").
+			Select("user_agent, ip_prefix, last_ip, created_at, last_seen_at").
+			Where("user_id = ? AND deleted_at IS NULL", userID).Order("created_at")},
+		{&export.EmailVerifications, r.db.Table("email_verifications ev").
+			Select("apps.app_id, ev.created_at, ev.expires_at, ev.used_at").
+			Joins("LEFT JOIN applications apps ON apps.id = ev.application_id").
+			Where("ev.user_id = ?", userID).Order("ev.created_at")},
+		{&export.PasswordResets, r.db.Table("password_resets pr").
+			Select("apps.app_id, pr.created_at, pr.expires_at, pr.used_at").
+			Joins("LEFT JOIN applications apps ON apps.id = pr.application_id").
+			Where("pr.user_id = ?", userID).Order("pr.created_at")},
+		{&export.EmailChanges, r.db.Table("email_changes").
+			Select("old_email, new_email, created_at, used_at, canceled_at").
+			Where("user_id = ?", userID).Order("created_at")},
+		{&export.LoginAttempts, r.db.Table("login_attempts la").
+			Select("apps.app_id, la.ip, la.user_agent, la.success, la.reason, la.created_at").
+			Joins("LEFT JOIN applications apps ON apps.id = la.application_id").
+			Where("la.user_id = ?", userID).Order("la.created_at")},
+		{&export.Lockouts, r.db.Table("user_lockouts ul").
+			Select("apps.app_id, ul.failed_logins, ul.lock_count, ul.locked_until, ul.last_failed_at").
+			Joins("LEFT JOIN applications apps ON apps.id = ul.application_id").
+			Where("ul.user_id = ? AND ul.deleted_at IS NULL", userID)},
+		{&export.Attributes, r.db.Table("user_attributes ua").
+			Select("apps.app_id, ua.data, ua.updated_at").
+			Joins("LEFT JOIN applications apps ON apps.id = ua.application_id").
+			Where("ua.user_id = ?", userID)},
+		{&export.Deletions, r.db.Table("account_deletions").
+			Select("created_at, scheduled_for, canceled_at").
+			Where("user_id = ?", userID).Order("created_at")},
+	}
+	for _, q := range queries {
+		if err := q.query.Scan(q.dest).Error; err != nil {
+			return export, err
+		}
+	}
+	return export, nil
+}
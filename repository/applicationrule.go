@@ -42,8 +42,9 @@ func (r *applicationRuleRepository) CreateDefaultRules(appID uint) error {
 	defs := []model.ApplicationRules{
 		{ApplicationID: appID, Code: "REGISTRATION_POLICY", Value: []byte(`{"mode": "public", "require_email_verification": true, "default_role": "USER"}`), IsActive: true},
 		{ApplicationID: appID, Code: "PWD_POLICY", Value: []byte(`{"min_length": 8, "require_uppercase": true, "require_numbers": true, "require_symbols": true}`), IsActive: true},
-		{ApplicationID: appID, Code: "SESSION_POLICY", Value: []byte(`{"token_expiration_minutes": 1440, "max_failed_logins": 5}`), IsActive: true},
+		{ApplicationID: appID, Code: "SESSION_POLICY", Value: []byte(`{"token_expiration_minutes": 1440, "max_failed_logins": 5, "lockout_minutes": 15, "max_lockout_minutes": 1440, "challenge_after_failures": 3, "challenge_after_ip_failures": 20, "challenge_difficulty": 18, "challenge_type": "pow"}`), IsActive: true},
 		{ApplicationID: appID, Code: "AUTHZ_POLICY", Value: []byte(`{"enable_roles": true}`), IsActive: true},
+		{ApplicationID: appID, Code: "LOGIN_POLICY", Value: []byte(`{"identifiers": ["email"]}`), IsActive: true},
 	}
 	for _, d := range defs {
 		if err := r.db.Create(&d).Error; err != nil {
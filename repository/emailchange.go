@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"errors"
+	"peak-auth/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailChangeNotPending = errors.New("la solicitud de cambio de email ya fue usada, cancelada o expiró")
+	ErrEmailTaken            = errors.New("el email ya está en uso por otra cuenta")
+)
+
+type EmailChangeRepository interface {
+	Create(change *model.EmailChange) error
+	FindPendingByToken(plainToken string) (*model.EmailChange, error)
+	FindPendingByCancelToken(plainToken string) (*model.EmailChange, error)
+	CancelPendingByUser(userID uint) error
+	Cancel(id uint) error
+	Confirm(change *model.EmailChange) error
+}
+
+type emailChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailChangeRepository construye el repositorio de solicitudes de cambio de email.
+func NewEmailChangeRepository(db *gorm.DB) EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+func (r *emailChangeRepository) Create(change *model.EmailChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *emailChangeRepository) pending() *gorm.DB {
+	return r.db.Where("used_at IS NULL AND canceled_at IS NULL AND expires_at > ?", time.Now())
+}
+
+// FindPendingByToken busca la solicitud vigente por el token enviado a la nueva dirección.
+func (r *emailChangeRepository) FindPendingByToken(plainToken string) (*model.EmailChange, error) {
+	hash := sha256.Sum256([]byte(plainToken))
+	var change model.EmailChange
+	if err := r.pending().Where("token_hash = ?", hash[:]).First(&change).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// FindPendingByCancelToken busca la solicitud vigente por el token enviado a la dirección anterior.
+func (r *emailChangeRepository) FindPendingByCancelToken(plainToken string) (*model.EmailChange, error) {
+	hash := sha256.Sum256([]byte(plainToken))
+	var change model.EmailChange
+	if err := r.pending().Where("cancel_token_hash = ?", hash[:]).First(&change).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// CancelPendingByUser invalida las solicitudes previas al crear una nueva.
+func (r *emailChangeRepository) CancelPendingByUser(userID uint) error {
+	return r.db.Model(&model.EmailChange{}).
+		Where("user_id = ? AND used_at IS NULL AND canceled_at IS NULL", userID).
+		Update("canceled_at", time.Now()).Error
+}
+
+func (r *emailChangeRepository) Cancel(id uint) error {
+	res := r.db.Model(&model.EmailChange{}).
+		Where("id = ? AND used_at IS NULL AND canceled_at IS NULL", id).
+		Update("canceled_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrEmailChangeNotPending
+	}
+	return nil
+}
+
+// Confirm aplica el cambio en una única transacción: consume la solicitud, verifica que el
+// nuevo email siga libre, reemplaza el email (sólo si no cambió desde la solicitud) y revoca
+// todas las sesiones del usuario, incluidos los access tokens que todavía llevan el email anterior.
+func (r *emailChangeRepository) Confirm(change *model.EmailChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.EmailChange{}).
+			Where("id = ? AND used_at IS NULL AND canceled_at IS NULL", change.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrEmailChangeNotPending
+		}
+
+		var count int64
+		if err := tx.Model(&model.User{}).Where("LOWER(email) = LOWER(?) AND id <> ?", change.NewEmail, change.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailTaken
+		}
+
+		res = tx.Model(&model.User{}).
+			Where("id = ? AND email = ?", change.UserID, change.OldEmail).
+			Update("email", change.NewEmail)
+		if res.Error != nil {
+			// El índice único sobre LOWER(users.email) cubre la carrera entre el conteo y el update
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrEmailChangeNotPending
+		}
+
+		if err := tx.Where("user_id = ?", change.UserID).Delete(&model.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return revokeAccessTokens(tx, change.UserID, time.Now())
+	})
+}
@@ -12,6 +12,9 @@ type EmailVerificationRepository interface {
 	CreateEmailVerification(verification *model.EmailVerification) error
 	FindEmailVerification(token string) (*model.EmailVerification, error)
 	UpdateUsedAt(verification *model.EmailVerification, usedAt time.Time) error
+	FindLastCreatedAt(userID uint) (time.Time, error)
+	ExpirePendingByUser(userID, exceptID uint) error
+	Delete(id uint) error
 }
 
 type emailVerification struct {
@@ -47,3 +50,23 @@ func (r *emailVerification) FindEmailVerification(plainToken string) (*model.Ema
 func (r *emailVerification) UpdateUsedAt(verification *model.EmailVerification, usedAt time.Time) error {
 	return r.db.Model(verification).Update("used_at", usedAt).Error
 }
+
+// FindLastCreatedAt devuelve cuándo se emitió el último token de verificación del usuario.
+func (r *emailVerification) FindLastCreatedAt(userID uint) (time.Time, error) {
+	var last model.EmailVerification
+	err := r.db.Where("user_id = ?", userID).Order("created_at desc").First(&last).Error
+	return last.CreatedAt, err
+}
+
+// ExpirePendingByUser invalida los tokens de verificación aún no usados del usuario, salvo `exceptID`.
+func (r *emailVerification) ExpirePendingByUser(userID, exceptID uint) error {
+	now := time.Now()
+	return r.db.Model(&model.EmailVerification{}).
+		Where("user_id = ? AND id <> ? AND used_at IS NULL AND expires_at > ?", userID, exceptID, now).
+		Update("expires_at", now).Error
+}
+
+// Delete descarta una verificación cuyo email no se pudo enviar.
+func (r *emailVerification) Delete(id uint) error {
+	return r.db.Delete(&model.EmailVerification{}, id).Error
+}
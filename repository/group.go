@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"peak-auth/model"
+	"peak-auth/response"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GroupRepository interface {
+	FindAll() ([]response.GroupRow, error)
+	FindByID(id uint) (model.Group, error)
+	FindByName(name string) (model.Group, error)
+	Create(group *model.Group) error
+	Update(group *model.Group) error
+	Delete(group model.Group) error
+	FindMembers(groupID uint) ([]response.GroupMemberRow, error)
+	AddMembers(groupID uint, userIDs []uint) (int64, error)
+	RemoveMember(groupID, userID uint) (int64, error)
+	FindGrants(groupID uint) ([]response.GroupGrantRow, error)
+	AddGrant(groupID, appID, roleID uint) error
+	RemoveGrant(groupID, appID, roleID uint) (int64, error)
+	FindByUser(userID uint) ([]model.Group, error)
+}
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupRepository construye el repositorio de grupos de usuarios.
+func NewGroupRepository(db *gorm.DB) GroupRepository {
+	return &groupRepository{db: db}
+}
+
+// FindAll devuelve los grupos ordenados por nombre con la cantidad de miembros y roles concedidos.
+func (r *groupRepository) FindAll() ([]response.GroupRow, error) {
+	var rows []response.GroupRow
+	err := r.db.Model(&model.Group{}).
+		Select("groups.id, groups.name, groups.description, groups.created_at, " +
+			"(SELECT COUNT(*) FROM group_members gm WHERE gm.group_id = groups.id) AS member_count, " +
+			"(SELECT COUNT(*) FROM group_roles gr WHERE gr.group_id = groups.id) AS grant_count").
+		Order("groups.name").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *groupRepository) FindByID(id uint) (model.Group, error) {
+	var group model.Group
+	err := r.db.First(&group, id).Error
+	return group, err
+}
+
+func (r *groupRepository) FindByName(name string) (model.Group, error) {
+	var group model.Group
+	err := r.db.Where("LOWER(name) = LOWER(?)", name).First(&group).Error
+	return group, err
+}
+
+func (r *groupRepository) Create(group *model.Group) error {
+	return r.db.Create(group).Error
+}
+
+func (r *groupRepository) Update(group *model.Group) error {
+	return r.db.Save(group).Error
+}
+
+// Delete borra el grupo con sus miembros y concesiones. El borrado es físico para liberar el nombre.
+func (r *groupRepository) Delete(group model.Group) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&model.GroupRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&group).Error
+	})
+}
+
+// FindMembers devuelve los miembros del grupo ordenados por email.
+func (r *groupRepository) FindMembers(groupID uint) ([]response.GroupMemberRow, error) {
+	var rows []response.GroupMemberRow
+	err := r.db.Table("group_members gm").
+		Select("users.id AS user_id, users.email, users.is_active, profiles.first_name, profiles.last_name, gm.created_at").
+		Joins("JOIN users ON users.id = gm.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN profiles ON profiles.user_id = users.id AND profiles.deleted_at IS NULL").
+		Where("gm.group_id = ?", groupID).
+		Order("users.email").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// AddMembers agrega los usuarios al grupo ignorando a los que ya son miembros y devuelve cuántos se agregaron.
+func (r *groupRepository) AddMembers(groupID uint, userIDs []uint) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+	members := make([]model.GroupMember, len(userIDs))
+	for i, id := range userIDs {
+		members[i] = model.GroupMember{GroupID: groupID, UserID: id}
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&members)
+	return result.RowsAffected, result.Error
+}
+
+func (r *groupRepository) RemoveMember(groupID, userID uint) (int64, error) {
+	result := r.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{})
+	return result.RowsAffected, result.Error
+}
+
+// FindGrants devuelve los roles concedidos al grupo, por aplicación.
+func (r *groupRepository) FindGrants(groupID uint) ([]response.GroupGrantRow, error) {
+	var rows []response.GroupGrantRow
+	err := r.db.Table("group_roles gr").
+		Select("applications.id AS application_id, applications.app_id, applications.name AS app_name, roles.id AS role_id, roles.name AS role_name").
+		Joins("JOIN applications ON applications.id = gr.application_id AND applications.deleted_at IS NULL").
+		Joins("JOIN roles ON roles.id = gr.role_id AND roles.deleted_at IS NULL").
+		Where("gr.group_id = ?", groupID).
+		Order("applications.name, roles.name").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *groupRepository) AddGrant(groupID, appID, roleID uint) error {
+	grant := model.GroupRole{GroupID: groupID, ApplicationID: appID, RoleID: roleID}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&grant).Error
+}
+
+func (r *groupRepository) RemoveGrant(groupID, appID, roleID uint) (int64, error) {
+	result := r.db.Where("group_id = ? AND application_id = ? AND role_id = ?", groupID, appID, roleID).Delete(&model.GroupRole{})
+	return result.RowsAffected, result.Error
+}
+
+// FindByUser devuelve los grupos a los que pertenece el usuario.
+func (r *groupRepository) FindByUser(userID uint) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Joins("JOIN group_members gm ON gm.group_id = groups.id").
+		Where("gm.user_id = ?", userID).
+		Order("groups.name").
+		Find(&groups).Error
+	return groups, err
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"peak-auth/model"
+	"peak-auth/response"
+
+	"gorm.io/gorm"
+)
+
+type ImpersonationRepository interface {
+	Create(entry *model.ImpersonationLog) error
+	FindRecentByUser(userID uint, limit int) ([]response.UserImpersonationRow, error)
+}
+
+type impersonationRepository struct {
+	db *gorm.DB
+}
+
+// NewImpersonationRepository construye el repositorio del registro de suplantaciones.
+func NewImpersonationRepository(db *gorm.DB) ImpersonationRepository {
+	return &impersonationRepository{db: db}
+}
+
+func (r *impersonationRepository) Create(entry *model.ImpersonationLog) error {
+	return r.db.Create(entry).Error
+}
+
+// FindRecentByUser devuelve las últimas suplantaciones sufridas por el usuario.
+func (r *impersonationRepository) FindRecentByUser(userID uint, limit int) ([]response.UserImpersonationRow, error) {
+	var rows []response.UserImpersonationRow
+	err := r.db.Table("impersonation_logs il").
+		Select("il.actor_id, users.email AS actor_email, applications.name AS app_name, il.reason, il.ip, il.created_at, il.expires_at").
+		Joins("LEFT JOIN users ON users.id = il.actor_id").
+		Joins("LEFT JOIN applications ON applications.id = il.application_id").
+		Where("il.user_id = ? AND il.deleted_at IS NULL", userID).
+		Order("il.created_at DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"errors"
+	"peak-auth/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvitationNotPending = errors.New("la invitación ya fue aceptada, revocada o expiró")
+
+type InvitationRepository interface {
+	Create(invitation *model.Invitation) error
+	AddRole(invitation *model.Invitation, role *model.Role) error
+	FindPendingByAppAndEmail(appID uint, email string) (*model.Invitation, error)
+	FindPendingByApp(appID uint) ([]model.Invitation, error)
+	FindByIDAndApp(id, appID uint) (*model.Invitation, error)
+	FindPendingByToken(plainToken string) (*model.Invitation, error)
+	Renew(id uint, tokenHash []byte, expiresAt time.Time) error
+	Revoke(id uint) error
+	Accept(invitation *model.Invitation, user *model.User, profile *model.Profile) error
+	AcceptClaimingAccount(invitation *model.Invitation, userID uint, hashedPassword string, username *string) error
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository construye el repositorio de invitaciones.
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) Create(invitation *model.Invitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// AddRole suma un rol a una invitación pendiente.
+func (r *invitationRepository) AddRole(invitation *model.Invitation, role *model.Role) error {
+	return r.db.Model(invitation).Association("Roles").Append(role)
+}
+
+// pending filtra las invitaciones que todavía pueden aceptarse (incluye las vencidas
+// sólo si includeExpired).
+func (r *invitationRepository) pending(includeExpired bool) *gorm.DB {
+	query := r.db.Where("invitations.status = ?", model.InvitationPending)
+	if !includeExpired {
+		query = query.Where("invitations.expires_at > ?", time.Now())
+	}
+	return query
+}
+
+func (r *invitationRepository) FindPendingByAppAndEmail(appID uint, email string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := r.pending(true).Preload("Roles").
+		Where("application_id = ? AND LOWER(email) = LOWER(?)", appID, email).
+		First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindPendingByApp lista las invitaciones sin aceptar ni revocar de la app, vencidas incluidas,
+// para que el admin pueda reenviarlas.
+func (r *invitationRepository) FindPendingByApp(appID uint) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+	err := r.pending(true).Preload("Roles").Preload("InvitedBy").
+		Where("application_id = ?", appID).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *invitationRepository) FindByIDAndApp(id, appID uint) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := r.db.Preload("Roles").Preload("Application").
+		Where("id = ? AND application_id = ?", id, appID).
+		First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindPendingByToken busca la invitación vigente por el token del link enviado por email.
+func (r *invitationRepository) FindPendingByToken(plainToken string) (*model.Invitation, error) {
+	hash := sha256.Sum256([]byte(plainToken))
+	var invitation model.Invitation
+	err := r.pending(false).Preload("Roles").Preload("Application").
+		Where("token_hash = ?", hash[:]).
+		First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// Renew reemplaza el token (el link anterior deja de servir) y extiende el vencimiento.
+func (r *invitationRepository) Renew(id uint, tokenHash []byte, expiresAt time.Time) error {
+	return r.db.Model(&model.Invitation{}).
+		Where("id = ? AND status = ?", id, model.InvitationPending).
+		Updates(map[string]interface{}{
+			"token_hash":   tokenHash,
+			"expires_at":   expiresAt,
+			"last_sent_at": time.Now(),
+			"send_count":   gorm.Expr("send_count + 1"),
+		}).Error
+}
+
+func (r *invitationRepository) Revoke(id uint) error {
+	result := r.db.Model(&model.Invitation{}).
+		Where("id = ? AND status = ?", id, model.InvitationPending).
+		Updates(map[string]interface{}{"status": model.InvitationRevoked, "revoked_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotPending
+	}
+	return nil
+}
+
+// Accept consume la invitación y, en la misma transacción, crea la cuenta (si user no
+// tiene ID) y le asigna en la app los roles invitados que todavía no tenga.
+func (r *invitationRepository) Accept(invitation *model.Invitation, user *model.User, profile *model.Profile) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := markAccepted(tx, invitation.ID); err != nil {
+			return err
+		}
+
+		if user.ID == 0 {
+			if err := tx.Create(user).Error; err != nil {
+				return err
+			}
+			profile.UserID = user.ID
+			if err := tx.Create(profile).Error; err != nil {
+				return err
+			}
+		}
+
+		return grantInvitedRoles(tx, invitation, user.ID)
+	})
+}
+
+// AcceptClaimingAccount consume la invitación de un email cuya cuenta nunca se verificó:
+// reemplaza la contraseña y el username (nil lo borra) por los elegidos por el invitado,
+// marca la cuenta como verificada y cierra sus sesiones antes de asignarle los roles.
+func (r *invitationRepository) AcceptClaimingAccount(invitation *model.Invitation, userID uint, hashedPassword string, username *string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := markAccepted(tx, invitation.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND is_verified = ?", userID, false).
+			Updates(map[string]interface{}{"password": hashedPassword, "username": username, "is_verified": true})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// La cuenta se verificó mientras tanto: ya no se puede reclamar
+			return ErrInvitationNotPending
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := revokeAccessTokens(tx, userID, now); err != nil {
+			return err
+		}
+
+		return grantInvitedRoles(tx, invitation, userID)
+	})
+}
+
+// markAccepted pasa la invitación a aceptada si sigue pendiente y vigente.
+func markAccepted(tx *gorm.DB, invitationID uint) error {
+	now := time.Now()
+	result := tx.Model(&model.Invitation{}).
+		Where("id = ? AND status = ? AND expires_at > ?", invitationID, model.InvitationPending, now).
+		Updates(map[string]interface{}{"status": model.InvitationAccepted, "accepted_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotPending
+	}
+	return nil
+}
+
+// grantInvitedRoles asigna en la app los roles invitados que el usuario todavía no tenga.
+func grantInvitedRoles(tx *gorm.DB, invitation *model.Invitation, userID uint) error {
+	for _, role := range invitation.Roles {
+		var count int64
+		err := tx.Model(&model.UserApplicationRole{}).
+			Where("user_id = ? AND application_id = ? AND role_id = ?", userID, invitation.ApplicationID, role.ID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		uar := model.UserApplicationRole{UserID: userID, ApplicationID: invitation.ApplicationID, RoleID: role.ID}
+		if err := tx.Create(&uar).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&model.Invitation{}).Where("id = ?", invitation.ID).Update("accepted_user_id", userID).Error
+}
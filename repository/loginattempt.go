@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"peak-auth/model"
+	"peak-auth/response"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginAttemptRepository interface {
+	Create(attempt *model.LoginAttempt) error
+	CountFailuresByIP(ip string, since time.Time) (int64, error)
+	FindRecentByUser(userID uint, limit int) ([]response.UserLoginRow, error)
+	CountFailuresByIdentifier(appID uint, identifier string, since time.Time) (int64, error)
+	DeleteOlderThan(before time.Time) (int64, error)
+	ConsumeChallenge(tokenHash string, expiresAt time.Time) (bool, error)
+	DeleteExpiredChallenges(now time.Time) error
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository construye el repositorio de intentos de login.
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Create(attempt *model.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// CountFailuresByIP cuenta los intentos fallidos desde una IP a partir de `since`.
+func (r *loginAttemptRepository) CountFailuresByIP(ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("ip = ? AND success = ? AND created_at > ?", ip, false, since).
+		Count(&count).Error
+	return count, err
+}
+
+// FindRecentByUser devuelve los últimos intentos de login del usuario.
+func (r *loginAttemptRepository) FindRecentByUser(userID uint, limit int) ([]response.UserLoginRow, error) {
+	var rows []response.UserLoginRow
+	err := r.db.Table("login_attempts la").
+		Select("applications.name AS app_name, la.ip, la.user_agent, la.success, la.reason, la.created_at").
+		Joins("LEFT JOIN applications ON applications.id = la.application_id").
+		Where("la.user_id = ? AND la.deleted_at IS NULL", userID).
+		Order("la.created_at DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CountFailuresByIdentifier cuenta los intentos fallidos con ese email/username en la app a
+// partir de `since`, exista o no la cuenta.
+func (r *loginAttemptRepository) CountFailuresByIdentifier(appID uint, identifier string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("application_id = ? AND LOWER(email) = LOWER(?) AND success = ? AND created_at > ?", appID, identifier, false, since).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteOlderThan borra definitivamente los intentos anteriores a `before` (retención).
+func (r *loginAttemptRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("created_at < ?", before).Delete(&model.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// ConsumeChallenge marca el desafío como usado. Devuelve false si ya se había canjeado.
+func (r *loginAttemptRepository) ConsumeChallenge(tokenHash string, expiresAt time.Time) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UsedChallenge{TokenHash: tokenHash, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteExpiredChallenges borra los desafíos canjeados que ya vencieron.
+func (r *loginAttemptRepository) DeleteExpiredChallenges(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&model.UsedChallenge{}).Error
+}
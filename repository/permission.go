@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"peak-auth/model"
+
+	"gorm.io/gorm"
+)
+
+type PermissionRepository interface {
+	FindByApp(appID uint) ([]model.Permission, error)
+	FindByCode(appID uint, code string) (model.Permission, error)
+	Create(permission *model.Permission) error
+	Delete(permission model.Permission) error
+	FindGrantsByApp(appID uint) ([]model.RolePermission, error)
+	ReplaceRoleGrants(appID, roleID uint, permissionIDs []uint) error
+	FindCodesByRoles(appID uint, roleIDs []uint) ([]string, error)
+}
+
+type permissionRepository struct {
+	db *gorm.DB
+}
+
+// NewPermissionRepository construye el repositorio del catálogo de permisos.
+func NewPermissionRepository(db *gorm.DB) PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+// FindByApp devuelve el catálogo de permisos de la aplicación ordenado por código.
+func (r *permissionRepository) FindByApp(appID uint) ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.Where("application_id = ?", appID).Order("code").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *permissionRepository) FindByCode(appID uint, code string) (model.Permission, error) {
+	var permission model.Permission
+	err := r.db.Where("application_id = ? AND code = ?", appID, code).First(&permission).Error
+	return permission, err
+}
+
+func (r *permissionRepository) Create(permission *model.Permission) error {
+	return r.db.Create(permission).Error
+}
+
+// Delete borra el permiso y lo quita de todos los roles. El borrado es físico para liberar el código.
+func (r *permissionRepository) Delete(permission model.Permission) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("permission_id = ?", permission.ID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&permission).Error
+	})
+}
+
+// FindGrantsByApp devuelve las concesiones rol-permiso de los permisos de la aplicación.
+func (r *permissionRepository) FindGrantsByApp(appID uint) ([]model.RolePermission, error) {
+	var grants []model.RolePermission
+	err := r.db.Table("role_permissions rp").
+		Select("rp.role_id, rp.permission_id").
+		Joins("JOIN permissions p ON p.id = rp.permission_id").
+		Where("p.application_id = ? AND p.deleted_at IS NULL", appID).
+		Scan(&grants).Error
+	return grants, err
+}
+
+// ReplaceRoleGrants deja al rol exactamente con `permissionIDs` dentro de la aplicación.
+func (r *permissionRepository) ReplaceRoleGrants(appID, roleID uint, permissionIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ? AND permission_id IN (SELECT id FROM permissions WHERE application_id = ?)", roleID, appID).
+			Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(permissionIDs) == 0 {
+			return nil
+		}
+		grants := make([]model.RolePermission, len(permissionIDs))
+		for i, id := range permissionIDs {
+			grants[i] = model.RolePermission{RoleID: roleID, PermissionID: id}
+		}
+		return tx.Create(&grants).Error
+	})
+}
+
+// FindCodesByRoles devuelve los códigos de permiso (sin repetir) concedidos a los roles en la app.
+func (r *permissionRepository) FindCodesByRoles(appID uint, roleIDs []uint) ([]string, error) {
+	var codes []string
+	if len(roleIDs) == 0 {
+		return codes, nil
+	}
+	err := r.db.Model(&model.Permission{}).
+		Distinct("permissions.code").
+		Joins("JOIN role_permissions rp ON rp.permission_id = permissions.id").
+		Where("permissions.application_id = ? AND rp.role_id IN ?", appID, roleIDs).
+		Order("permissions.code").
+		Pluck("permissions.code", &codes).Error
+	return codes, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"peak-auth/model"
+	"peak-auth/response"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,6 +13,10 @@ type RefreshTokenRepository interface {
 	FindByToken(token string) (model.RefreshToken, error)
 	DeleteByToken(token string) error
 	DeleteByUser(userID uint) error
+	DeleteByUserExcept(userID uint, keepToken string) error
+	FindByRevokeHash(hash []byte) (model.RefreshToken, error)
+	RevokeSession(rt model.RefreshToken, at time.Time) error
+	FindActiveByUser(userID uint) ([]response.UserSessionRow, error)
 }
 
 type refreshTokenRepository struct {
@@ -39,3 +44,40 @@ func (r *refreshTokenRepository) DeleteByToken(token string) error {
 func (r *refreshTokenRepository) DeleteByUser(userID uint) error {
 	return r.db.Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error
 }
+
+// DeleteByUserExcept elimina todas las sesiones del usuario salvo la indicada.
+func (r *refreshTokenRepository) DeleteByUserExcept(userID uint, keepToken string) error {
+	return r.db.Where("user_id = ? AND token <> ?", userID, keepToken).Delete(&model.RefreshToken{}).Error
+}
+
+// FindByRevokeHash busca la sesión asociada al link de revocación enviado por email.
+func (r *refreshTokenRepository) FindByRevokeHash(hash []byte) (model.RefreshToken, error) {
+	var rt model.RefreshToken
+	err := r.db.Where("revoke_token_hash = ?", hash).First(&rt).Error
+	return rt, err
+}
+
+// RevokeSession elimina la sesión y revoca los access tokens del usuario emitidos hasta `at`.
+// La deny-list es por usuario: las demás sesiones siguen vivas y obtienen un access token
+// nuevo en su próximo refresh.
+func (r *refreshTokenRepository) RevokeSession(rt model.RefreshToken, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token = ?", rt.Token).Delete(&model.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return revokeAccessTokens(tx, rt.UserID, at)
+	})
+}
+
+// FindActiveByUser lista las sesiones vigentes del usuario con su app y dispositivo.
+func (r *refreshTokenRepository) FindActiveByUser(userID uint) ([]response.UserSessionRow, error) {
+	var rows []response.UserSessionRow
+	err := r.db.Table("refresh_tokens rt").
+		Select("rt.id, applications.app_id, applications.name AS app_name, ud.user_agent, ud.last_ip, rt.created_at, rt.expires_at").
+		Joins("LEFT JOIN applications ON applications.id = rt.application_id").
+		Joins("LEFT JOIN user_devices ud ON ud.id = rt.user_device_id").
+		Where("rt.user_id = ? AND rt.deleted_at IS NULL AND rt.expires_at > ?", userID, time.Now()).
+		Order("rt.created_at DESC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -10,10 +10,15 @@ import (
 
 type RoleRepository interface {
 	FindByRoleName(roleName string) (model.Role, error)
+	FindByNameForApp(appID uint, roleName string) (model.Role, error)
+	ExistsInAnyApp(roleName string) (bool, error)
 	Create(role *model.Role) error
 	FindAll() ([]model.Role, error)
+	FindForApp(appID uint) ([]model.Role, error)
 	CountUsersWithRole(roleID uint) (int64, error)
 	Delete(roleID uint) error
+	FindInheritances() ([]model.RoleInheritance, error)
+	ReplaceParents(roleID uint, parentIDs []uint) error
 }
 
 type roleRepository struct {
@@ -24,18 +29,47 @@ func NewRoleRepositoryRepository(db *gorm.DB) RoleRepository {
 	return &roleRepository{db: db}
 }
 
+// FindAll devuelve los roles globales (los de cada app se listan con FindForApp).
 func (r *roleRepository) FindAll() ([]model.Role, error) {
 	var roles []model.Role
-	err := r.db.Find(&roles).Error
+	err := r.db.Where("application_id IS NULL").Find(&roles).Error
 	return roles, err
 }
 
+// FindForApp devuelve los roles válidos en la app: los globales y los propios, ordenados por nombre.
+func (r *roleRepository) FindForApp(appID uint) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.db.Where("application_id IS NULL OR application_id = ?", appID).
+		Order("application_id NULLS FIRST, name").
+		Find(&roles).Error
+	return roles, err
+}
+
+// FindByRoleName busca un rol global por nombre.
 func (r *roleRepository) FindByRoleName(roleName string) (model.Role, error) {
 	var role model.Role
-	err := r.db.Where("name = ?", strings.ToUpper(roleName)).First(&role).Error
+	err := r.db.Where("name = ? AND application_id IS NULL", strings.ToUpper(roleName)).First(&role).Error
+	return role, err
+}
+
+// FindByNameForApp busca un rol válido en la app: propio de la app o global.
+func (r *roleRepository) FindByNameForApp(appID uint, roleName string) (model.Role, error) {
+	var role model.Role
+	err := r.db.Where("name = ? AND (application_id IS NULL OR application_id = ?)", strings.ToUpper(roleName), appID).
+		Order("application_id NULLS LAST").
+		First(&role).Error
 	return role, err
 }
 
+// ExistsInAnyApp indica si alguna aplicación define un rol propio con ese nombre.
+func (r *roleRepository) ExistsInAnyApp(roleName string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Role{}).
+		Where("name = ? AND application_id IS NOT NULL", strings.ToUpper(roleName)).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *roleRepository) Create(role *model.Role) error {
 	return r.db.Create(role).Error
 }
@@ -49,9 +83,45 @@ func (r *roleRepository) CountUsersWithRole(roleID uint) (int64, error) {
 	return count, err
 }
 
-// Delete elimina de forma lógica un rol.
+// Delete elimina de forma lógica un rol, sus permisos en todas las apps, su herencia y las
+// concesiones a grupos.
 func (r *roleRepository) Delete(roleID uint) error {
-	return r.db.Model(&model.Role{}).Where("id = ?", roleID).Updates(map[string]interface{}{
-		"deleted_at": time.Now(),
-	}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ? OR parent_id = ?", roleID, roleID).Delete(&model.RoleInheritance{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", roleID).Delete(&model.GroupRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Role{}).Where("id = ?", roleID).Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+		}).Error
+	})
+}
+
+// FindInheritances devuelve todas las relaciones de herencia con ambos roles cargados.
+func (r *roleRepository) FindInheritances() ([]model.RoleInheritance, error) {
+	var inheritances []model.RoleInheritance
+	err := r.db.Preload("Role").Preload("Parent").Find(&inheritances).Error
+	return inheritances, err
+}
+
+// ReplaceParents deja al rol heredando exactamente de `parentIDs`.
+func (r *roleRepository) ReplaceParents(roleID uint, parentIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).Delete(&model.RoleInheritance{}).Error; err != nil {
+			return err
+		}
+		if len(parentIDs) == 0 {
+			return nil
+		}
+		inheritances := make([]model.RoleInheritance, len(parentIDs))
+		for i, id := range parentIDs {
+			inheritances[i] = model.RoleInheritance{RoleID: roleID, ParentID: id}
+		}
+		return tx.Omit("Role", "Parent").Create(&inheritances).Error
+	})
 }
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/response"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,10 +15,16 @@ type UserRepository interface {
 	CreateWithProfile(user *model.User, profile *model.Profile) error
 	VerifyUserEmail(userID uint, verificationID uint) error
 	FindByEmail(email string) (model.User, error)
+	FindByUsername(username string) (model.User, error)
+	ExistsByUsername(username string) (bool, error)
 	FindById(ID uint) (model.User, error)
 	Update(user *model.User) error
 	UpdateColumn(column string, value interface{}, id uint) error
+	SaveProfile(profile *model.Profile) error
 	ExistsByEmail(email string) (bool, error)
+	SearchDirectory(filter request.UserDirectoryFilter) ([]response.UserDirectoryRow, int64, error)
+	FindAppsByUserIDs(userIDs []uint) ([]response.UserDirectoryApp, error)
+	UpdateStatus(userID uint, active bool, reason string, changedBy uint) error
 }
 
 type userRepository struct {
@@ -33,13 +42,27 @@ func (r *userRepository) FindAll() ([]model.User, error) {
 	return users, err
 }
 
-// FindByEmail devuelve el usuario a través de email.
+// FindByEmail devuelve el usuario a través de email, sin distinguir mayúsculas.
 func (r *userRepository) FindByEmail(email string) (model.User, error) {
 	var user model.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Where("LOWER(email) = LOWER(?)", strings.TrimSpace(email)).First(&user).Error
 	return user, err
 }
 
+// FindByUsername devuelve el usuario a través del username (ya normalizado).
+func (r *userRepository) FindByUsername(username string) (model.User, error) {
+	var user model.User
+	err := r.db.Where("username = ?", username).First(&user).Error
+	return user, err
+}
+
+// ExistsByUsername indica si el username (ya normalizado) está en uso, incluso por cuentas borradas.
+func (r *userRepository) ExistsByUsername(username string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&model.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
+
 // FindById devuelve el usuario a través de ID.
 func (r *userRepository) FindById(id uint) (model.User, error) {
 	var user model.User
@@ -57,10 +80,15 @@ func (r *userRepository) UpdateColumn(column string, value interface{}, id uint)
 	return r.db.Model(&model.User{}).Where("id = ? AND is_active = ?", id, true).Update(column, value).Error
 }
 
-// ExistsByEmail verifica si ese email ya existe.
+// SaveProfile crea o actualiza el perfil de un usuario.
+func (r *userRepository) SaveProfile(profile *model.Profile) error {
+	return r.db.Save(profile).Error
+}
+
+// ExistsByEmail verifica si ese email ya existe (sin distinguir mayúsculas; ignora borrados).
 func (r *userRepository) ExistsByEmail(email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&model.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.Model(&model.User{}).Where("LOWER(email) = LOWER(?)", strings.TrimSpace(email)).Count(&count).Error
 	return count > 0, err
 }
 
@@ -86,3 +114,125 @@ func (r *userRepository) VerifyUserEmail(userID uint, verificationID uint) error
 		return tx.Model(&model.EmailVerification{}).Where("id = ?", verificationID).Update("used_at", time.Now()).Error
 	})
 }
+
+// UpdateStatus activa o desactiva al usuario registrando el motivo. Al desactivarlo
+// elimina sus refresh tokens y revoca los access tokens ya emitidos.
+func (r *userRepository) UpdateStatus(userID uint, active bool, reason string, changedBy uint) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"is_active":         active,
+			"status_reason":     reason,
+			"status_changed_at": now,
+			"status_changed_by": changedBy,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if active {
+			return nil
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return revokeAccessTokens(tx, userID, now)
+	})
+}
+
+// directorySorts traduce el parámetro `sort` del directorio a un ORDER BY seguro.
+var directorySorts = map[string]string{
+	"created_desc": "users.created_at DESC",
+	"created_asc":  "users.created_at ASC",
+	"email_asc":    "users.email ASC",
+	"email_desc":   "users.email DESC",
+	"name_asc":     "profiles.last_name ASC, profiles.first_name ASC",
+	"login_desc":   "users.last_login DESC",
+}
+
+// SearchDirectory busca usuarios de todas las aplicaciones con filtros y paginación.
+func (r *userRepository) SearchDirectory(filter request.UserDirectoryFilter) ([]response.UserDirectoryRow, int64, error) {
+	var rows []response.UserDirectoryRow
+	var total int64
+
+	activeLock := "SELECT 1 FROM user_lockouts ul WHERE ul.user_id = users.id AND ul.deleted_at IS NULL AND ul.locked_until > NOW()"
+	query := r.db.Table("users").
+		Joins("LEFT JOIN profiles ON profiles.user_id = users.id AND profiles.deleted_at IS NULL").
+		Where("users.deleted_at IS NULL")
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		like := "%" + search + "%"
+		query = query.Where("users.email ILIKE ? OR users.username ILIKE ? OR profiles.first_name ILIKE ? OR profiles.last_name ILIKE ? OR CONCAT(profiles.first_name, ' ', profiles.last_name) ILIKE ?", like, like, like, like, like)
+	}
+	switch filter.Verified {
+	case "yes":
+		query = query.Where("users.is_verified = ?", true)
+	case "no":
+		query = query.Where("users.is_verified = ?", false)
+	}
+	switch filter.Active {
+	case "yes":
+		query = query.Where("users.is_active = ?", true)
+	case "no":
+		query = query.Where("users.is_active = ?", false)
+	}
+	switch filter.Locked {
+	case "yes":
+		query = query.Where("EXISTS (" + activeLock + ")")
+	case "no":
+		query = query.Where("NOT EXISTS (" + activeLock + ")")
+	}
+	if filter.NoApp {
+		// Sin roles directos ni grupos que concedan alguno
+		query = query.Where("NOT EXISTS (SELECT 1 FROM user_application_roles uar WHERE uar.user_id = users.id AND uar.deleted_at IS NULL)").
+			Where("NOT EXISTS (SELECT 1 FROM group_members gm JOIN group_roles gr ON gr.group_id = gm.group_id WHERE gm.user_id = users.id)")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := directorySorts[filter.Sort]
+	if !ok {
+		order = directorySorts["created_desc"]
+	}
+
+	err := query.
+		Select("users.id, users.email, users.is_verified, users.is_active, users.last_login, users.created_at, profiles.first_name, profiles.last_name, " +
+			"(SELECT MAX(ul.locked_until) FROM user_lockouts ul WHERE ul.user_id = users.id AND ul.deleted_at IS NULL) AS locked_until").
+		Order(order + ", users.id ASC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Scan(&rows).Error
+
+	return rows, total, err
+}
+
+// FindAppsByUserIDs devuelve las aplicaciones de cada usuario indicado, incluidas las que sólo
+// alcanza a través de un grupo. RoleName lista únicamente los roles asignados directamente.
+func (r *userRepository) FindAppsByUserIDs(userIDs []uint) ([]response.UserDirectoryApp, error) {
+	var apps []response.UserDirectoryApp
+	if len(userIDs) == 0 {
+		return apps, nil
+	}
+	grants := r.db.Raw(`
+		SELECT uar.user_id, uar.application_id, uar.role_id, TRUE AS direct
+		FROM user_application_roles uar
+		WHERE uar.user_id IN ? AND uar.deleted_at IS NULL
+		UNION ALL
+		SELECT gm.user_id, gr.application_id, gr.role_id, FALSE AS direct
+		FROM group_members gm JOIN group_roles gr ON gr.group_id = gm.group_id
+		WHERE gm.user_id IN ?`, userIDs, userIDs)
+	err := r.db.Table("(?) AS grants", grants).
+		Select("grants.user_id, applications.id AS application_id, applications.app_id, applications.name, " +
+			"COALESCE(string_agg(DISTINCT roles.name, ', ') FILTER (WHERE grants.direct), '') AS role_name").
+		Joins("JOIN applications ON applications.id = grants.application_id AND applications.deleted_at IS NULL").
+		Joins("JOIN roles ON roles.id = grants.role_id").
+		Group("grants.user_id, applications.id, applications.app_id, applications.name").
+		Order("applications.name ASC").
+		Scan(&apps).Error
+	return apps, err
+}
@@ -1,16 +1,19 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"peak-auth/model"
 	"peak-auth/response"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserApplicationRoleRepository interface {
 	AssignRole(userID, appID, roleID uint) error
+	AssignTimedRole(userID, appID, roleID uint, startsAt, expiresAt *time.Time) error
 	RevokeAccess(userID, appID uint) error
 	FindRolesByUserAndApp(userID, appID uint) ([]model.Role, error)
 	CountUsersByApp(appID uint) (int64, error)
@@ -19,6 +22,10 @@ type UserApplicationRoleRepository interface {
 	GetUserRolesInApp(userID, appID uint) ([]string, error)
 	GetUsersWithRolesByApp(appID uint) ([]response.UserAppRow, error)
 	GetUsersWithRolesByAppPaginated(appID uint, page, limit int) ([]response.UserAppRow, int64, error)
+	FindGroupsGrantingApp(userID, appID uint) ([]string, error)
+	EarliestGrantExpiry(userID, appID uint) (*time.Time, error)
+	ExportByApp(appID uint) ([]response.UserExportRow, error)
+	PurgeExpired(now time.Time) ([]response.ExpiredGrantRow, error)
 }
 
 type userApplicationRoleRepository struct {
@@ -30,21 +37,57 @@ func NewUserApplicationRoleRepository(db *gorm.DB) UserApplicationRoleRepository
 	return &userApplicationRoleRepository{db: db}
 }
 
-// AssignRole asigna el `roleID` al `userID` dentro de la `appID`, evitando duplicados.
+// AssignRole asigna el `roleID` al `userID` dentro de la `appID`, evitando duplicados. Si ya lo
+// tiene no toca su vigencia (un rol temporal no pasa a ser permanente).
 func (r *userApplicationRoleRepository) AssignRole(userID, appID, roleID uint) error {
-	var existing model.UserApplicationRole
+	var count int64
 	// Evitamos duplicados: misma app, mismo usuario, mismo rol
+	err := r.db.Model(&model.UserApplicationRole{}).
+		Where("user_id = ? AND application_id = ? AND role_id = ?", userID, appID, roleID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("el usuario ya tiene este rol en esta aplicación")
+	}
+
+	uar := model.UserApplicationRole{UserID: userID, ApplicationID: appID, RoleID: roleID}
+	return r.db.Create(&uar).Error
+}
+
+// AssignTimedRole asigna el rol con una vigencia opcional: nil en `startsAt` es "desde ya"
+// y nil en `expiresAt` es "sin vencimiento". Si el usuario ya tiene el rol (aunque esté vencido
+// y el barrido todavía no lo haya eliminado) se reemplaza su vigencia: así se extiende, acorta
+// o vuelve a otorgar. Si la vigencia se acorta, los access tokens ya emitidos se revocan: su
+// `exp` se acotó a la vigencia anterior.
+func (r *userApplicationRoleRepository) AssignTimedRole(userID, appID, roleID uint, startsAt, expiresAt *time.Time) error {
+	var existing model.UserApplicationRole
 	err := r.db.Where("user_id = ? AND application_id = ? AND role_id = ?", userID, appID, roleID).
 		First(&existing).Error
-
 	if err == nil {
-		return fmt.Errorf("el usuario ya tiene este rol en esta aplicación")
+		if err := r.db.Model(&existing).
+			Updates(map[string]interface{}{"starts_at": startsAt, "expires_at": expiresAt}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		startsLater := startsAt != nil && startsAt.After(now) && (existing.StartsAt == nil || startsAt.After(*existing.StartsAt))
+		endsSooner := expiresAt != nil && (existing.ExpiresAt == nil || expiresAt.Before(*existing.ExpiresAt))
+		if startsLater || endsSooner {
+			return revokeAccessTokens(r.db, userID, now)
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
 	}
 
 	uar := model.UserApplicationRole{
 		UserID:        userID,
 		ApplicationID: appID,
 		RoleID:        roleID,
+		StartsAt:      startsAt,
+		ExpiresAt:     expiresAt,
 	}
 	return r.db.Create(&uar).Error
 }
@@ -65,12 +108,31 @@ func (r *userApplicationRoleRepository) RevokeAccess(userID, appID uint) error {
 	return nil
 }
 
-// FindRolesByUserAndApp obtiene los roles que tiene un usuario en una aplicación.
+// effectiveRoleIDs expande los roles del usuario en la app: los asignados directamente, los
+// concedidos a sus grupos y los que éstos heredan (role_inheritances). UNION descarta los
+// repetidos, así que un ciclo no recursa indefinidamente. Usa los parámetros @user y @app.
+// Las asignaciones directas fuera de su vigencia (starts_at/expires_at) no cuentan.
+const effectiveRoleIDs = `
+	WITH RECURSIVE effective(role_id) AS (
+		SELECT uar.role_id FROM user_application_roles uar
+		WHERE uar.user_id = @user AND uar.application_id = @app AND uar.deleted_at IS NULL
+			AND (uar.starts_at IS NULL OR uar.starts_at <= NOW())
+			AND (uar.expires_at IS NULL OR uar.expires_at > NOW())
+		UNION
+		SELECT gr.role_id FROM group_roles gr
+		JOIN group_members gm ON gm.group_id = gr.group_id
+		WHERE gm.user_id = @user AND gr.application_id = @app
+		UNION
+		SELECT ri.parent_id FROM role_inheritances ri JOIN effective e ON ri.role_id = e.role_id
+	)
+	SELECT role_id FROM effective`
+
+// FindRolesByUserAndApp obtiene los roles efectivos de un usuario en una aplicación:
+// asignados, concedidos por grupos y heredados.
 func (r *userApplicationRoleRepository) FindRolesByUserAndApp(userID, appID uint) ([]model.Role, error) {
 	var roles []model.Role
-	err := r.db.Table("roles").
-		Joins("JOIN user_application_roles uar ON uar.role_id = roles.id").
-		Where("uar.user_id = ? AND uar.application_id = ?", userID, appID).
+	err := r.db.Where("id IN ("+effectiveRoleIDs+")", map[string]interface{}{"user": userID, "app": appID}).
+		Order("name").
 		Find(&roles).Error
 	return roles, err
 }
@@ -88,6 +150,8 @@ func (r *userApplicationRoleRepository) HasRole(userID uint, roleName string) (b
 	err := r.db.Model(&model.UserApplicationRole{}).
 		Joins("JOIN roles r ON r.id = user_application_roles.role_id").
 		Where("user_application_roles.user_id = ? AND r.name = ?", userID, roleName).
+		Where("user_application_roles.starts_at IS NULL OR user_application_roles.starts_at <= NOW()").
+		Where("user_application_roles.expires_at IS NULL OR user_application_roles.expires_at > NOW()").
 		Count(&count).Error
 	if err != nil {
 		return false, err
@@ -106,12 +170,13 @@ func (r *userApplicationRoleRepository) GetUsersByApp(appID uint) ([]model.User,
 	return users, err
 }
 
+// GetUserRolesInApp devuelve los nombres de los roles efectivos (asignados, por grupos y heredados).
 func (r *userApplicationRoleRepository) GetUserRolesInApp(userID, appID uint) ([]string, error) {
 	var roles []string
 	err := r.db.Model(&model.Role{}).
-		Joins("JOIN user_application_roles uar ON uar.role_id = roles.id").
-		Where("uar.user_id = ? AND uar.application_id = ?", userID, appID).
-		Pluck("roles.name", &roles).Error
+		Where("id IN ("+effectiveRoleIDs+")", map[string]interface{}{"user": userID, "app": appID}).
+		Order("name").
+		Pluck("name", &roles).Error
 	return roles, err
 }
 
@@ -129,16 +194,23 @@ func (r *userApplicationRoleRepository) GetUsersWithRolesByApp(appID uint) ([]re
 	return rows, err
 }
 
+// groupGrants son las concesiones de grupo (miembro + rol) de cada usuario en una app.
+const groupGrants = `
+	FROM group_members gm
+	JOIN group_roles gr ON gr.group_id = gm.group_id
+	JOIN groups g ON g.id = gm.group_id`
+
 // GetUsersWithRolesByAppPaginated devuelve los usuarios con roles de forma paginada para una aplicación.
+// Incluye a quienes sólo acceden por un grupo: RoleName tiene los roles asignados directamente y
+// GroupNames/GroupRoleNames los que concede cada grupo.
 func (r *userApplicationRoleRepository) GetUsersWithRolesByAppPaginated(appID uint, page, limit int) ([]response.UserAppRow, int64, error) {
 	var rows []response.UserAppRow
 	var total int64
 
 	baseQuery := r.db.Table("users").
 		Joins("JOIN profiles ON profiles.user_id = users.id").
-		Joins("JOIN user_application_roles uar ON uar.user_id = users.id").
-		Joins("JOIN roles ON roles.id = uar.role_id").
-		Where("uar.application_id = ? AND uar.deleted_at IS NULL", appID)
+		Where("users.id IN (SELECT user_id FROM user_application_roles WHERE application_id = ? AND deleted_at IS NULL"+
+			" UNION SELECT gm.user_id"+groupGrants+" WHERE gr.application_id = ?)", appID, appID)
 
 	// Contar el total de registros (usuarios únicos) para esta consulta
 	if err := baseQuery.Distinct("users.id").Count(&total).Error; err != nil {
@@ -149,8 +221,15 @@ func (r *userApplicationRoleRepository) GetUsersWithRolesByAppPaginated(appID ui
 
 	// Realizar la consulta con paginación agrupando por usuario para juntar sus roles
 	err := baseQuery.
-		Select("users.id, users.email, users.is_verified, users.is_active, users.failed_logins, profiles.first_name, profiles.last_name, string_agg(roles.name, ', ') as role_name").
-		Group("users.id, users.email, users.is_verified, users.is_active, users.failed_logins, profiles.first_name, profiles.last_name").
+		Select("users.id, users.email, users.is_verified, users.is_active, COALESCE(ul.failed_logins, 0) as failed_logins, ul.locked_until, profiles.first_name, profiles.last_name, COALESCE(string_agg(roles.name, ', '), '') as role_name, "+
+			"MAX(uar.starts_at) FILTER (WHERE uar.starts_at > NOW()) as starts_at, MIN(uar.expires_at) as expires_at, "+
+			"(SELECT string_agg(DISTINCT g.name, ', ')"+groupGrants+" WHERE gm.user_id = users.id AND gr.application_id = ?) as group_names, "+
+			"(SELECT string_agg(DISTINCT gro.name, ', ')"+groupGrants+" JOIN roles gro ON gro.id = gr.role_id WHERE gm.user_id = users.id AND gr.application_id = ?) as group_role_names",
+			appID, appID).
+		Joins("LEFT JOIN user_application_roles uar ON uar.user_id = users.id AND uar.application_id = ? AND uar.deleted_at IS NULL", appID).
+		Joins("LEFT JOIN roles ON roles.id = uar.role_id").
+		Joins("LEFT JOIN user_lockouts ul ON ul.user_id = users.id AND ul.application_id = ? AND ul.deleted_at IS NULL", appID).
+		Group("users.id, users.email, users.is_verified, users.is_active, ul.failed_logins, ul.locked_until, profiles.first_name, profiles.last_name").
 		Order("users.email ASC").
 		Offset(offset).
 		Limit(limit).
@@ -158,3 +237,69 @@ func (r *userApplicationRoleRepository) GetUsersWithRolesByAppPaginated(appID ui
 
 	return rows, total, err
 }
+
+// EarliestGrantExpiry devuelve el vencimiento más próximo entre los roles temporales vigentes del
+// usuario en la app (nil si todos son permanentes), para acotar la vida de sus access tokens.
+func (r *userApplicationRoleRepository) EarliestGrantExpiry(userID, appID uint) (*time.Time, error) {
+	var expiresAt *time.Time
+	err := r.db.Model(&model.UserApplicationRole{}).
+		Select("MIN(expires_at)").
+		Where("user_id = ? AND application_id = ?", userID, appID).
+		Where("starts_at IS NULL OR starts_at <= NOW()").
+		Where("expires_at > NOW()").
+		Scan(&expiresAt).Error
+	return expiresAt, err
+}
+
+// FindGroupsGrantingApp devuelve los grupos del usuario que le conceden algún rol en la app.
+func (r *userApplicationRoleRepository) FindGroupsGrantingApp(userID, appID uint) ([]string, error) {
+	var names []string
+	err := r.db.Raw("SELECT DISTINCT g.name"+groupGrants+" WHERE gm.user_id = ? AND gr.application_id = ? ORDER BY g.name", userID, appID).
+		Scan(&names).Error
+	return names, err
+}
+
+// ExportByApp devuelve todos los usuarios de la app con sus roles agregados ("A|B").
+func (r *userApplicationRoleRepository) ExportByApp(appID uint) ([]response.UserExportRow, error) {
+	var rows []response.UserExportRow
+
+	err := r.db.Table("users").
+		Select("users.email, users.is_verified, users.is_active, profiles.first_name, profiles.last_name, string_agg(roles.name, '|' ORDER BY roles.name) as role_names").
+		Joins("LEFT JOIN profiles ON profiles.user_id = users.id AND profiles.deleted_at IS NULL").
+		Joins("JOIN user_application_roles uar ON uar.user_id = users.id").
+		Joins("JOIN roles ON roles.id = uar.role_id").
+		Where("uar.application_id = ? AND uar.deleted_at IS NULL AND users.deleted_at IS NULL", appID).
+		Group("users.id, users.email, users.is_verified, users.is_active, profiles.first_name, profiles.last_name").
+		Order("users.email ASC").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// PurgeExpired elimina lógicamente las asignaciones cuyo `expires_at` ya pasó y devuelve
+// cuáles fueron, con los datos necesarios para avisar a cada usuario.
+func (r *userApplicationRoleRepository) PurgeExpired(now time.Time) ([]response.ExpiredGrantRow, error) {
+	var rows []response.ExpiredGrantRow
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("user_application_roles uar").
+			Select("uar.id, uar.user_id, users.email, applications.name as app_name, roles.name as role_name, uar.expires_at").
+			Joins("JOIN users ON users.id = uar.user_id").
+			Joins("JOIN applications ON applications.id = uar.application_id").
+			Joins("JOIN roles ON roles.id = uar.role_id").
+			Where("uar.deleted_at IS NULL AND uar.expires_at <= ?", now).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "uar"}}).
+			Scan(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		return tx.Model(&model.UserApplicationRole{}).
+			Where("id IN ?", ids).
+			Update("deleted_at", now).Error
+	})
+	return rows, err
+}
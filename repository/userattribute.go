@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"peak-auth/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserAttributeRepository interface {
+	Find(userID, appID uint) (map[string]interface{}, error)
+	Save(userID, appID uint, values map[string]interface{}) error
+}
+
+type userAttributeRepository struct {
+	db *gorm.DB
+}
+
+// NewUserAttributeRepository construye el repositorio de atributos personalizados.
+func NewUserAttributeRepository(db *gorm.DB) UserAttributeRepository {
+	return &userAttributeRepository{db: db}
+}
+
+// Find devuelve los atributos del usuario en la app. Si no tiene, devuelve un mapa vacío.
+func (r *userAttributeRepository) Find(userID, appID uint) (map[string]interface{}, error) {
+	var attrs model.UserAttributes
+	err := r.db.Where("user_id = ? AND application_id = ?", userID, appID).First(&attrs).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(attrs.Data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Save reemplaza los atributos del usuario en la app.
+func (r *userAttributeRepository) Save(userID, appID uint, values map[string]interface{}) error {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "application_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(&model.UserAttributes{UserID: userID, ApplicationID: appID, Data: raw, UpdatedAt: time.Now()}).Error
+}
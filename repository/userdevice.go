@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"peak-auth/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserDeviceRepository interface {
+	FindByFingerprint(userID uint, fingerprint string) (model.UserDevice, error)
+	CountByUser(userID uint) (int64, error)
+	Create(device *model.UserDevice) error
+	Touch(id uint, ip string) error
+	Delete(id uint) error
+}
+
+type userDeviceRepository struct {
+	db *gorm.DB
+}
+
+// NewUserDeviceRepository construye el repositorio de dispositivos conocidos.
+func NewUserDeviceRepository(db *gorm.DB) UserDeviceRepository {
+	return &userDeviceRepository{db: db}
+}
+
+func (r *userDeviceRepository) FindByFingerprint(userID uint, fingerprint string) (model.UserDevice, error) {
+	var device model.UserDevice
+	err := r.db.Where("user_id = ? AND fingerprint = ?", userID, fingerprint).First(&device).Error
+	return device, err
+}
+
+func (r *userDeviceRepository) CountByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.UserDevice{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *userDeviceRepository) Create(device *model.UserDevice) error {
+	return r.db.Create(device).Error
+}
+
+// Touch actualiza la última vez que se vio el dispositivo.
+func (r *userDeviceRepository) Touch(id uint, ip string) error {
+	return r.db.Model(&model.UserDevice{}).Where("id = ?", id).
+		Updates(map[string]any{"last_seen_at": time.Now(), "last_ip": ip}).Error
+}
+
+// Delete olvida el dispositivo (borrado físico para que el próximo login vuelva a notificarse).
+func (r *userDeviceRepository) Delete(id uint) error {
+	return r.db.Unscoped().Delete(&model.UserDevice{}, id).Error
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"peak-auth/model"
+	"peak-auth/response"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserLockoutRepository interface {
+	FindByUserAndApp(userID, appID uint) (model.UserLockout, error)
+	RegisterFailure(userID, appID uint, now, resetBefore time.Time) (model.UserLockout, error)
+	Lock(id, maxFailedLogins uint, until time.Time) error
+	Reset(userID, appID uint) error
+	ResetAll(userID uint) error
+	FindByUser(userID uint) ([]response.UserLockoutRow, error)
+}
+
+type userLockoutRepository struct {
+	db *gorm.DB
+}
+
+// NewUserLockoutRepository construye el repositorio de bloqueos por usuario y aplicación.
+func NewUserLockoutRepository(db *gorm.DB) UserLockoutRepository {
+	return &userLockoutRepository{db: db}
+}
+
+// FindByUserAndApp devuelve el registro de bloqueo. Si no existe, devuelve uno vacío sin persistir.
+func (r *userLockoutRepository) FindByUserAndApp(userID, appID uint) (model.UserLockout, error) {
+	var lockout model.UserLockout
+	err := r.db.Where("user_id = ? AND application_id = ?", userID, appID).First(&lockout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.UserLockout{UserID: userID, ApplicationID: appID}, nil
+	}
+	return lockout, err
+}
+
+// RegisterFailure suma un intento fallido en un único upsert atómico y devuelve el registro
+// resultante. Si el último fallo es anterior a `resetBefore`, contador y backoff empiezan de cero.
+func (r *userLockoutRepository) RegisterFailure(userID, appID uint, now, resetBefore time.Time) (model.UserLockout, error) {
+	lockout := model.UserLockout{UserID: userID, ApplicationID: appID, FailedLogins: 1, LastFailedAt: &now}
+	stale := "user_lockouts.last_failed_at IS NOT NULL AND user_lockouts.last_failed_at < ?"
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "application_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_logins":  gorm.Expr("CASE WHEN "+stale+" THEN 1 ELSE user_lockouts.failed_logins + 1 END", resetBefore),
+				"lock_count":     gorm.Expr("CASE WHEN "+stale+" THEN 0 ELSE user_lockouts.lock_count END", resetBefore),
+				"last_failed_at": now,
+				"updated_at":     now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&lockout).Error
+	return lockout, err
+}
+
+// Lock bloquea la cuenta hasta `until` y reinicia el contador, sólo si sigue en el máximo de
+// fallos: entre fallos simultáneos, únicamente el primero aplica el bloqueo.
+func (r *userLockoutRepository) Lock(id, maxFailedLogins uint, until time.Time) error {
+	return r.db.Model(&model.UserLockout{}).
+		Where("id = ? AND failed_logins >= ?", id, maxFailedLogins).
+		Updates(map[string]interface{}{
+			"lock_count":    gorm.Expr("lock_count + 1"),
+			"locked_until":  until,
+			"failed_logins": 0,
+		}).Error
+}
+
+// Reset limpia contador, backoff y bloqueo del usuario en la aplicación.
+func (r *userLockoutRepository) Reset(userID, appID uint) error {
+	return r.db.Model(&model.UserLockout{}).
+		Where("user_id = ? AND application_id = ?", userID, appID).
+		Updates(map[string]interface{}{
+			"failed_logins": 0,
+			"lock_count":    0,
+			"locked_until":  nil,
+		}).Error
+}
+
+// ResetAll desbloquea al usuario en todas las aplicaciones.
+func (r *userLockoutRepository) ResetAll(userID uint) error {
+	return r.db.Model(&model.UserLockout{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_logins": 0,
+			"lock_count":    0,
+			"locked_until":  nil,
+		}).Error
+}
+
+// FindByUser lista los contadores y bloqueos del usuario en cada aplicación.
+func (r *userLockoutRepository) FindByUser(userID uint) ([]response.UserLockoutRow, error) {
+	var rows []response.UserLockoutRow
+	err := r.db.Table("user_lockouts ul").
+		Select("applications.app_id, applications.name AS app_name, ul.failed_logins, ul.lock_count, ul.locked_until, ul.last_failed_at").
+		Joins("JOIN applications ON applications.id = ul.application_id").
+		Where("ul.user_id = ? AND ul.deleted_at IS NULL AND (ul.failed_logins > 0 OR ul.locked_until IS NOT NULL)", userID).
+		Order("applications.name ASC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,15 @@
+package request
+
+// AuthorizeCheck es una pregunta de autorización: ¿puede el usuario `Subject` (el "sub" del
+// token) hacer `Action` sobre `Resource`? Se evalúa como el permiso "resource:action".
+type AuthorizeCheck struct {
+	Subject  string `json:"subject"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// AuthorizeRequest admite una sola decisión (campos en la raíz) o un lote en `checks`.
+type AuthorizeRequest struct {
+	AuthorizeCheck
+	Checks []AuthorizeCheck `json:"checks"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email,max=100"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
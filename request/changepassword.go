@@ -0,0 +1,8 @@
+package request
+
+type ChangePasswordRequest struct {
+	CurrentPassword     string `json:"current_password" binding:"required"`
+	NewPassword         string `json:"new_password" binding:"required,min=6"`
+	RevokeOtherSessions bool   `json:"revoke_other_sessions"`
+	RefreshToken        string `json:"refresh_token"` // Sesión actual que se conserva al revocar las demás
+}
@@ -0,0 +1,5 @@
+package request
+
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
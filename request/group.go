@@ -0,0 +1,17 @@
+package request
+
+type GroupRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// GroupMembersRequest admite IDs (selección en el directorio) y emails (lista pegada), o ambos.
+type GroupMembersRequest struct {
+	UserIDs []uint   `json:"user_ids"`
+	Emails  []string `json:"emails"`
+}
+
+type GroupRoleRequest struct {
+	AppID string `json:"app_id" binding:"required"`
+	Role  string `json:"role" binding:"required,max=100"`
+}
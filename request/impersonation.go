@@ -0,0 +1,10 @@
+package request
+
+type ImpersonationRequest struct {
+	AppID  string `json:"app_id" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=255"`
+
+	// Se completan desde el contexto HTTP, no del body
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
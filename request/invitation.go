@@ -0,0 +1,10 @@
+package request
+
+type AcceptInvitationRequest struct {
+	Token           string `form:"token" binding:"required"`
+	Username        string `form:"username"` // Opcional: para ingresar también con username
+	FirstName       string `form:"first_name" binding:"max=50"`
+	LastName        string `form:"last_name" binding:"max=50"`
+	Password        string `form:"password"`
+	ConfirmPassword string `form:"confirm_password"`
+}
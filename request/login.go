@@ -1,6 +1,40 @@
 package request
 
+import "strings"
+
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required"`
+	// Email acepta también un username (según la LOGIN_POLICY de la app)
+	Email    string `json:"email" binding:"required_without=Username"`
+	Username string `json:"username"`
 	Password string `json:"password" binding:"required"`
+
+	// Desafío exigido tras fallos sospechosos (ver POST /api/v1/challenge)
+	ChallengeToken    string `json:"challenge_token"`
+	ChallengeSolution string `json:"challenge_solution"`
+	CaptchaToken      string `json:"captcha_token"`
+
+	// Datos del cliente completados por el controlador
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// Identifier devuelve el email o username con el que se intenta ingresar.
+func (r LoginRequest) Identifier() string {
+	if r.Email != "" {
+		return strings.TrimSpace(r.Email)
+	}
+	return strings.TrimSpace(r.Username)
+}
+
+type ChallengeRequest struct {
+	Email    string `json:"email" binding:"required_without=Username"`
+	Username string `json:"username"`
+}
+
+// Identifier devuelve el email o username para el que se pide el desafío.
+func (r ChallengeRequest) Identifier() string {
+	if r.Email != "" {
+		return strings.TrimSpace(r.Email)
+	}
+	return strings.TrimSpace(r.Username)
 }
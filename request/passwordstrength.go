@@ -0,0 +1,9 @@
+package request
+
+type PasswordStrengthRequest struct {
+	Password  string `json:"password" binding:"required"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type PermissionRequest struct {
+	Code        string `json:"code" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,12 @@
+package request
+
+// UpdateProfileRequest es un PATCH: los campos omitidos no se modifican.
+type UpdateProfileRequest struct {
+	Username  *string `json:"username"` // Identificador de login; no depende de la PROFILE_POLICY
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	BirthDate *string `json:"birth_date"` // YYYY-MM-DD, "" la borra
+	AvatarURL *string `json:"avatar_url"` // "" lo borra
+	// Atributos personalizados editables por el usuario; null borra el atributo
+	Attributes map[string]interface{} `json:"attributes"`
+}
@@ -3,3 +3,7 @@ package request
 type Role struct {
 	RoleName string `json:"role"`
 }
+
+type RoleParentsRequest struct {
+	Parents []string `json:"parents"`
+}
@@ -5,7 +5,8 @@ import (
 )
 
 type UserRequest struct {
-	IsActive bool `json:"is_active"`
+	IsActive bool   `json:"is_active"`
+	Reason   string `json:"reason" binding:"max=255"`
 }
 
 func (r UserRequest) ToModel() (model.User, error) {
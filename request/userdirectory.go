@@ -0,0 +1,14 @@
+package request
+
+// UserDirectoryFilter son los filtros del directorio global de usuarios (query string).
+// Los filtros booleanos aceptan "yes", "no" o vacío (sin filtrar).
+type UserDirectoryFilter struct {
+	Search   string `form:"q"`
+	Verified string `form:"verified"`
+	Active   string `form:"active"`
+	Locked   string `form:"locked"`
+	NoApp    bool   `form:"no_app"`
+	Sort     string `form:"sort"`
+	Page     int    `form:"page"`
+	Limit    int    `form:"-"`
+}
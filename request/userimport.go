@@ -0,0 +1,153 @@
+package request
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formatos aceptados por la importación/exportación masiva de usuarios.
+const (
+	UserImportFormatCSV  = "csv"
+	UserImportFormatJSON = "json"
+)
+
+// MaxUserImportRows limita la cantidad de filas procesadas en una importación.
+const MaxUserImportRows = 5000
+
+// UserImportRow es una fila de la importación. Line es la línea de origen (CSV) o la
+// posición en el arreglo (JSON), para el reporte de errores.
+type UserImportRow struct {
+	Line         int      `json:"-"`
+	Email        string   `json:"email"`
+	Username     string   `json:"username"`
+	FirstName    string   `json:"first_name"`
+	LastName     string   `json:"last_name"`
+	Roles        []string `json:"roles"`
+	Verified     bool     `json:"verified"`
+	PasswordHash string   `json:"password_hash"`
+}
+
+// UserImportOptions controla la ejecución de la importación. ByRoot e ImportedBy no vienen
+// del formulario: los fija el controlador según quién importa.
+type UserImportOptions struct {
+	DryRun          bool `form:"dry_run"`
+	SendInvitations bool `form:"send_invitations"`
+	ByRoot          bool `form:"-"` // Habilita credenciales (password_hash, verified) y el rol IMPERSONATOR
+	ImportedBy      uint `form:"-"`
+}
+
+// userImportColumns son las columnas reconocidas en la cabecera del CSV.
+var userImportColumns = []string{"email", "username", "first_name", "last_name", "roles", "verified", "password_hash"}
+
+// ParseUserImport lee las filas de un archivo CSV (con cabecera) o JSON (arreglo de objetos).
+// En el CSV los roles se separan con "|".
+func ParseUserImport(format string, r io.Reader) ([]UserImportRow, error) {
+	var rows []UserImportRow
+	var err error
+
+	switch format {
+	case UserImportFormatCSV:
+		rows, err = parseUserImportCSV(r)
+	case UserImportFormatJSON:
+		rows, err = parseUserImportJSON(r)
+	default:
+		return nil, fmt.Errorf("formato no soportado: usá csv o json")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("el archivo no contiene usuarios")
+	}
+	if len(rows) > MaxUserImportRows {
+		return nil, fmt.Errorf("el archivo supera el máximo de %d usuarios", MaxUserImportRows)
+	}
+	return rows, nil
+}
+
+func parseUserImportCSV(r io.Reader) ([]UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %w", err)
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		index[name] = i
+	}
+	if _, ok := index["email"]; !ok {
+		return nil, fmt.Errorf("la cabecera del CSV debe incluir la columna email (columnas: %s)", strings.Join(userImportColumns, ", "))
+	}
+
+	var rows []UserImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV inválido: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := UserImportRow{
+			Line:         line,
+			Email:        field("email"),
+			Username:     field("username"),
+			FirstName:    field("first_name"),
+			LastName:     field("last_name"),
+			PasswordHash: field("password_hash"),
+		}
+		for _, role := range strings.Split(field("roles"), "|") {
+			if role = strings.TrimSpace(role); role != "" {
+				row.Roles = append(row.Roles, role)
+			}
+		}
+		if verified := field("verified"); verified != "" {
+			row.Verified = parseImportBool(verified)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseUserImportJSON(r io.Reader) ([]UserImportRow, error) {
+	var rows []UserImportRow
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("JSON inválido: se espera un arreglo de usuarios")
+	}
+	for i := range rows {
+		rows[i].Line = i + 1
+	}
+	return rows, nil
+}
+
+// parseImportBool acepta los valores habituales de una planilla (true/1/si/yes/x).
+func parseImportBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "si", "sí", "yes", "y", "x":
+		return true
+	}
+	b, _ := strconv.ParseBool(value)
+	return b
+}
@@ -0,0 +1,107 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AccountExport es el archivo con todos los datos que peak-auth guarda de un usuario.
+type AccountExport struct {
+	ExportedAt         time.Time                  `json:"exported_at"`
+	User               AccountExportUser          `json:"user"`
+	Profile            AccountExportProfile       `json:"profile"`
+	ApplicationRoles   []AccountExportRole        `json:"application_roles"`
+	Sessions           []AccountExportSession     `json:"sessions"`
+	Devices            []AccountExportDevice      `json:"devices"`
+	EmailVerifications []AccountExportToken       `json:"email_verifications"`
+	PasswordResets     []AccountExportToken       `json:"password_resets"`
+	EmailChanges       []AccountExportEmailChange `json:"email_changes"`
+	LoginAttempts      []AccountExportLogin       `json:"login_attempts"`
+	Lockouts           []AccountExportLockout     `json:"lockouts"`
+	Attributes         []AccountExportAttributes  `json:"attributes"`
+	Deletions          []AccountExportDeletion    `json:"deletions"`
+}
+
+type AccountExportUser struct {
+	ID         uint      `json:"id"`
+	Email      string    `json:"email"`
+	IsActive   bool      `json:"is_active"`
+	IsVerified bool      `json:"is_verified"`
+	LastLogin  time.Time `json:"last_login"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type AccountExportProfile struct {
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	BirthDate time.Time `json:"birth_date"`
+	AvatarURL string    `json:"avatar_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AccountExportRole struct {
+	AppID     string     `json:"app_id"`
+	AppName   string     `json:"app_name"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"assigned_at"`
+	DeletedAt *time.Time `json:"revoked_at"`
+}
+
+type AccountExportSession struct {
+	AppID     string    `json:"app_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AccountExportDevice struct {
+	UserAgent  string    `json:"user_agent"`
+	IPPrefix   string    `json:"ip_prefix"`
+	LastIP     string    `json:"last_ip"`
+	CreatedAt  time.Time `json:"first_seen_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type AccountExportToken struct {
+	AppID     string     `json:"app_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+type AccountExportEmailChange struct {
+	OldEmail   string     `json:"old_email"`
+	NewEmail   string     `json:"new_email"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UsedAt     *time.Time `json:"confirmed_at"`
+	CanceledAt *time.Time `json:"canceled_at"`
+}
+
+type AccountExportLogin struct {
+	AppID     string    `json:"app_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountExportLockout struct {
+	AppID        string     `json:"app_id"`
+	FailedLogins uint       `json:"failed_logins"`
+	LockCount    uint       `json:"lock_count"`
+	LockedUntil  *time.Time `json:"locked_until"`
+	LastFailedAt *time.Time `json:"last_failed_at"`
+}
+
+type AccountExportAttributes struct {
+	AppID     string          `json:"app_id"`
+	Data      json.RawMessage `json:"values"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type AccountExportDeletion struct {
+	CreatedAt    time.Time  `json:"requested_at"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	CanceledAt   *time.Time `json:"canceled_at"`
+}
@@ -0,0 +1,16 @@
+package response
+
+// AuthorizeDecision es el resultado de una consulta de autorización con sus motivos.
+type AuthorizeDecision struct {
+	Subject  string   `json:"subject"`
+	Action   string   `json:"action"`
+	Resource string   `json:"resource"`
+	Allowed  bool     `json:"allowed"`
+	Decision string   `json:"decision"` // "allow" o "deny"
+	Reasons  []string `json:"reasons"`
+}
+
+// AuthorizeBatch son las decisiones de un lote, en el mismo orden que las consultas.
+type AuthorizeBatch struct {
+	Decisions []AuthorizeDecision `json:"decisions"`
+}
@@ -0,0 +1,12 @@
+package response
+
+import "time"
+
+type ChallengeResponse struct {
+	Type       string    `json:"type"` // "pow" o "captcha"
+	Token      string    `json:"token,omitempty"`
+	Difficulty int       `json:"difficulty,omitempty"`
+	Algorithm  string    `json:"algorithm,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+	SiteKey    string    `json:"site_key,omitempty"`
+}
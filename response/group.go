@@ -0,0 +1,49 @@
+package response
+
+import (
+	"peak-auth/model"
+	"time"
+)
+
+// GroupRow es un grupo en el listado de la consola, con sus totales.
+type GroupRow struct {
+	ID          uint
+	Name        string
+	Description string
+	MemberCount int64
+	GrantCount  int64
+	CreatedAt   time.Time
+}
+
+// GroupMemberRow es un miembro de un grupo.
+type GroupMemberRow struct {
+	UserID    uint
+	Email     string
+	FirstName string
+	LastName  string
+	IsActive  bool
+	CreatedAt time.Time // alta en el grupo
+}
+
+// GroupGrantRow es un rol concedido al grupo en una aplicación.
+type GroupGrantRow struct {
+	ApplicationID uint
+	AppID         string
+	AppName       string
+	RoleID        uint
+	RoleName      string
+}
+
+// GroupDetail es la ficha de un grupo: miembros y roles concedidos.
+type GroupDetail struct {
+	Group   model.Group
+	Members []GroupMemberRow
+	Grants  []GroupGrantRow
+}
+
+// GroupMembersResult resume el alta masiva de miembros.
+type GroupMembersResult struct {
+	Added    int64    `json:"added"`
+	Skipped  int64    `json:"skipped"`   // ya eran miembros
+	NotFound []string `json:"not_found"` // emails o IDs sin usuario
+}
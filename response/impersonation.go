@@ -0,0 +1,10 @@
+package response
+
+import "time"
+
+// ImpersonationToken es el access token emitido al suplantar a un usuario (sin refresh token).
+type ImpersonationToken struct {
+	AccessToken string    `json:"access_token"`
+	AppID       string    `json:"app_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
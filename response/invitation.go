@@ -0,0 +1,13 @@
+package response
+
+import "time"
+
+// InvitationView son los datos que ve el invitado en la página de aceptación.
+type InvitationView struct {
+	Email       string
+	AppName     string
+	Roles       []string
+	ExpiresAt   time.Time
+	HasAccount  bool // El email ya tiene cuenta: sólo se le suman los roles
+	SetPassword bool // La cuenta nunca se verificó: el invitado elige una contraseña nueva
+}
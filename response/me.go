@@ -0,0 +1,26 @@
+package response
+
+import "time"
+
+type ProfileResponse struct {
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	BirthDate *string `json:"birth_date"` // YYYY-MM-DD
+	AvatarURL string  `json:"avatar_url"`
+}
+
+// MeResponse es la vista del usuario autenticado en la app de su token.
+type MeResponse struct {
+	ID             uint            `json:"id"`
+	Email          string          `json:"email"`
+	Username       *string         `json:"username"`
+	IsVerified     bool            `json:"is_verified"`
+	LastLogin      time.Time       `json:"last_login"`
+	Profile        ProfileResponse `json:"profile"`
+	AppID          string          `json:"app_id"`
+	Roles          []string        `json:"roles"`
+	EditableFields []string        `json:"editable_fields"`
+	// Atributos personalizados de la app (ATTRIBUTE_SCHEMA)
+	Attributes    map[string]interface{} `json:"attributes"`
+	EditableAttrs []string               `json:"editable_attributes"`
+}
@@ -0,0 +1,9 @@
+package response
+
+import "peak-auth/utils"
+
+type PasswordStrengthResponse struct {
+	utils.PasswordStrength
+	MinScore   int  `json:"min_score"`
+	Acceptable bool `json:"acceptable"`
+}
@@ -0,0 +1,16 @@
+package response
+
+import "peak-auth/model"
+
+// RolePermissionsRow son los permisos concedidos a un rol dentro de una aplicación.
+type RolePermissionsRow struct {
+	RoleID  uint
+	Name    string
+	Granted map[string]bool // por código de permiso
+}
+
+// PermissionCatalog es el catálogo de permisos de una app y su asignación a los roles.
+type PermissionCatalog struct {
+	Permissions []model.Permission
+	Roles       []RolePermissionsRow
+}
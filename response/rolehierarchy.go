@@ -0,0 +1,39 @@
+package response
+
+import (
+	"slices"
+	"strings"
+)
+
+// RoleHierarchy son los roles padre de cada rol válido en una aplicación, por nombre.
+type RoleHierarchy struct {
+	Parents map[string][]string
+}
+
+// Inherited devuelve los roles heredados (y no asignados directamente) a partir de la lista de
+// roles directos tal como la agregan las consultas ("A, B").
+func (h RoleHierarchy) Inherited(direct string) []string {
+	var inherited []string
+	seen := map[string]bool{}
+	queue := []string{}
+	for _, name := range strings.Split(direct, ",") {
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, parent := range h.Parents[name] {
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			inherited = append(inherited, parent)
+			queue = append(queue, parent)
+		}
+	}
+	slices.Sort(inherited)
+	return inherited
+}
@@ -1,5 +1,7 @@
 package response
 
+import "time"
+
 type UserAppRow struct {
 	ID         uint
 	Email      string
@@ -9,4 +11,30 @@ type UserAppRow struct {
 	IsVerified   bool
 	IsActive     bool
 	FailedLogins uint
+	LockedUntil  *time.Time
+	StartsAt     *time.Time // inicio más lejano entre los roles que todavía no entraron en vigencia
+	ExpiresAt    *time.Time // vencimiento más próximo entre los roles temporales
+	// Grupos que le conceden roles en la app y esos roles ("A, B"); vacíos si no hay
+	GroupNames     string
+	GroupRoleNames string
+}
+
+// IsLocked indica si el usuario tiene un bloqueo vigente en la aplicación.
+func (r UserAppRow) IsLocked() bool {
+	return r.LockedUntil != nil && time.Now().Before(*r.LockedUntil)
+}
+
+// IsPending indica si alguno de sus roles todavía no entró en vigencia.
+func (r UserAppRow) IsPending() bool {
+	return r.StartsAt != nil && time.Now().Before(*r.StartsAt)
+}
+
+// ExpiredGrantRow describe un rol temporal vencido que el barrido eliminó.
+type ExpiredGrantRow struct {
+	ID        uint
+	UserID    uint
+	Email     string
+	AppName   string
+	RoleName  string
+	ExpiresAt time.Time
 }
@@ -0,0 +1,11 @@
+package response
+
+import "peak-auth/utils"
+
+// UserAttributesView son los atributos personalizados de un usuario en una app junto con
+// la ATTRIBUTE_SCHEMA que los describe.
+type UserAttributesView struct {
+	AppID  string                      `json:"app_id"`
+	Schema []utils.AttributeDefinition `json:"schema"`
+	Values map[string]interface{}      `json:"values"`
+}
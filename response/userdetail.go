@@ -0,0 +1,80 @@
+package response
+
+import "time"
+
+type UserSessionRow struct {
+	ID        uint
+	AppID     string
+	AppName   string
+	UserAgent string
+	LastIP    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type UserLockoutRow struct {
+	AppID        string
+	AppName      string
+	FailedLogins uint
+	LockCount    uint
+	LockedUntil  *time.Time
+	LastFailedAt *time.Time
+}
+
+// IsLocked indica si el bloqueo en la aplicación sigue vigente.
+func (r UserLockoutRow) IsLocked() bool {
+	return r.LockedUntil != nil && time.Now().Before(*r.LockedUntil)
+}
+
+type UserLoginRow struct {
+	AppName   string
+	IP        string
+	UserAgent string
+	Success   bool
+	Reason    string
+	CreatedAt time.Time
+}
+
+type UserImpersonationRow struct {
+	ActorID    uint
+	ActorEmail string
+	AppName    string
+	Reason     string
+	IP         string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// UserDetail es la ficha de un usuario en la consola de administración.
+type UserDetail struct {
+	ID              uint
+	Email           string
+	Username        string
+	FirstName       string
+	LastName        string
+	BirthDate       time.Time
+	AvatarURL       string
+	IsVerified      bool
+	IsActive        bool
+	LastLogin       time.Time
+	CreatedAt       time.Time
+	Apps            []UserDirectoryApp
+	Lockouts        []UserLockoutRow
+	Sessions        []UserSessionRow
+	LoginAttempts   []UserLoginRow
+	Impersonations  []UserImpersonationRow
+	PendingDeletion *time.Time
+	StatusReason    string
+	StatusChangedAt *time.Time
+	StatusChangedBy *uint
+}
+
+// IsLocked indica si el usuario tiene un bloqueo vigente en alguna aplicación.
+func (d UserDetail) IsLocked() bool {
+	for _, l := range d.Lockouts {
+		if l.IsLocked() {
+			return true
+		}
+	}
+	return false
+}
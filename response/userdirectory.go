@@ -0,0 +1,31 @@
+package response
+
+import "time"
+
+// UserDirectoryApp es una aplicación a la que pertenece un usuario del directorio.
+type UserDirectoryApp struct {
+	UserID         uint
+	ApplicationID  uint
+	AppID          string
+	Name           string
+	RoleName       string   // Roles asignados directamente ("A, B")
+	InheritedRoles []string `gorm:"-"`
+}
+
+type UserDirectoryRow struct {
+	ID          uint
+	Email       string
+	FirstName   string
+	LastName    string
+	IsVerified  bool
+	IsActive    bool
+	LastLogin   time.Time
+	CreatedAt   time.Time
+	LockedUntil *time.Time
+	Apps        []UserDirectoryApp `gorm:"-"`
+}
+
+// IsLocked indica si el usuario tiene un bloqueo vigente en alguna aplicación.
+func (r UserDirectoryRow) IsLocked() bool {
+	return r.LockedUntil != nil && time.Now().Before(*r.LockedUntil)
+}
@@ -0,0 +1,44 @@
+package response
+
+// Resultado de cada fila de una importación masiva.
+const (
+	UserImportCreated   = "created"   // Usuario nuevo vinculado a la app
+	UserImportInvited   = "invited"   // Email sin cuenta: se lo invita a la app con sus roles
+	UserImportLinked    = "linked"    // Usuario existente al que se le asignaron roles
+	UserImportUnchanged = "unchanged" // Usuario existente que ya tenía todos los roles
+	UserImportFailed    = "failed"
+)
+
+type UserImportRowResult struct {
+	Line       int      `json:"line"`
+	Email      string   `json:"email"`
+	Status     string   `json:"status"`
+	Errors     []string `json:"errors,omitempty"`
+	Activation bool     `json:"activation,omitempty"` // Cuenta nueva sin verificar: recibe el link de activación
+}
+
+// UserImportReport resume una importación (o su validación en modo dry-run).
+type UserImportReport struct {
+	DryRun      bool                  `json:"dry_run"`
+	Applied     bool                  `json:"applied"`
+	Total       int                   `json:"total"`
+	Created     int                   `json:"created"`
+	Invited     int                   `json:"invited"`
+	Linked      int                   `json:"linked"`
+	Unchanged   int                   `json:"unchanged"`
+	Failed      int                   `json:"failed"`
+	Activations int                   `json:"activations"`
+	Rows        []UserImportRowResult `json:"rows"`
+}
+
+// UserExportRow es un usuario de la app en la exportación. RoleNames llega agregado
+// desde la consulta ("A|B") y Roles es su versión en lista.
+type UserExportRow struct {
+	Email      string   `json:"email"`
+	FirstName  string   `json:"first_name"`
+	LastName   string   `json:"last_name"`
+	RoleNames  string   `json:"-"`
+	Roles      []string `json:"roles" gorm:"-"`
+	IsVerified bool     `json:"verified"`
+	IsActive   bool     `json:"active"`
+}
@@ -4,6 +4,8 @@ import (
 	"peak-auth/app"
 	"peak-auth/controller"
 	"peak-auth/middleware"
+	"peak-auth/ratelimit"
+	"peak-auth/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,11 +24,26 @@ func SetupRoutes(r *gin.Engine, app *app.App) {
 		TokenManager: app.TokenManager,
 	}
 
+	invitationCtrl := &controller.InvitationController{
+		AppService: app.AppService,
+	}
+
+	authzCtrl := &controller.AuthorizationController{
+		AuthorizationService: app.AuthorizationService,
+	}
+
 	adminCtrl := &controller.AdminController{
-		AppService:  app.AppService,
-		UserService: app.UserService,
-		RuleService: app.RuleService,
-		RoleService: app.RoleService,
+		AppService:        app.AppService,
+		UserService:       app.UserService,
+		RuleService:       app.RuleService,
+		RoleService:       app.RoleService,
+		PermissionService: app.PermissionService,
+		GroupService:      app.GroupService,
+	}
+
+	// Límites por defecto de los endpoints públicos (sobrescribibles con RATE_LIMIT_POLICY)
+	rateLimit := func(route string, limits middleware.RouteLimits) gin.HandlerFunc {
+		return middleware.RateLimitMiddleware(app.RateLimiter, app.AppRepo, app.RuleService, route, limits)
 	}
 
 	// --- SETUP ---
@@ -36,14 +53,50 @@ func SetupRoutes(r *gin.Engine, app *app.App) {
 	// --- API V1 ---
 	api := r.Group("/api/v1")
 	{
-		api.POST("/login", userCtrl.Login)
-		api.POST("/register", userCtrl.Register)
-		api.POST("/refresh", userCtrl.Refresh)
+		api.POST("/login", rateLimit("login", middleware.RouteLimits{IP: ratelimit.PerMinute(20), Email: ratelimit.PerMinute(10)}), userCtrl.Login)
+		api.POST("/register", rateLimit("register", middleware.RouteLimits{IP: ratelimit.PerHour(20), Email: ratelimit.PerHour(5)}), userCtrl.Register)
+		api.POST("/refresh", rateLimit("refresh", middleware.RouteLimits{IP: ratelimit.PerMinute(60)}), userCtrl.Refresh)
+		api.POST("/password/strength", rateLimit("password_strength", middleware.RouteLimits{IP: ratelimit.PerMinute(60)}), userCtrl.PostPasswordStrength)
+		api.POST("/challenge", rateLimit("challenge", middleware.RouteLimits{IP: ratelimit.PerMinute(30)}), userCtrl.PostChallenge)
+
+		// Decisiones de autorización para las apps cliente. Las credenciales se validan antes del
+		// límite: sin ellas no se consume el cupo de la app
+		api.POST("/authorize", middleware.AppAuthMiddleware(app.AppRepo), rateLimit("authorize", middleware.RouteLimits{App: ratelimit.PerMinute(6000)}), authzCtrl.PostAuthorize)
 
 		// Verificación y Recuperación (activación)
-		api.GET("/verify", userCtrl.GetVerifyEmail)
+		api.GET("/verify", rateLimit("verify", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.GetVerifyEmail)
+		api.POST("/verify/resend", rateLimit("verify_resend", middleware.RouteLimits{IP: ratelimit.PerHour(20), Email: ratelimit.PerHour(5)}), userCtrl.PostResendVerification)
 		api.GET("/reset-password", userCtrl.GetResetPassword)
 		api.POST("/reset-password", userCtrl.PostResetPassword)
+
+		// Cierre de una sesión desde el link del aviso de nuevo dispositivo (GET confirma, POST cierra)
+		api.GET("/sessions/revoke", rateLimit("session_revoke", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.GetRevokeSession)
+		api.POST("/sessions/revoke", rateLimit("session_revoke", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.PostRevokeSession)
+
+		// Aceptación de invitaciones (link enviado por email)
+		api.GET("/invitations/accept", rateLimit("invitation", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), invitationCtrl.GetAcceptInvitation)
+		api.POST("/invitations/accept", rateLimit("invitation", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), invitationCtrl.PostAcceptInvitation)
+
+		// Cuenta del usuario autenticado (access token)
+		me := api.Group("/me")
+		me.Use(middleware.AuthMiddleware(app.TokenManager))
+		{
+			me.GET("", userCtrl.GetMe)
+			me.PATCH("", userCtrl.PatchMe)
+			me.POST("/password", middleware.DenyImpersonation(), rateLimit("change_password", middleware.RouteLimits{IP: ratelimit.PerMinute(20), User: ratelimit.PerHour(10)}), userCtrl.PostChangePassword)
+			me.POST("/email", middleware.DenyImpersonation(), userCtrl.PostChangeEmail)
+			me.GET("/export", middleware.DenyImpersonation(), userCtrl.GetExportAccount)
+			me.POST("/deletion", middleware.DenyImpersonation(), userCtrl.PostScheduleDeletion)
+			me.DELETE("/deletion", middleware.DenyImpersonation(), userCtrl.DeleteScheduledDeletion)
+		}
+
+		// Links del cambio de email (sin access token: llegan por correo)
+		api.GET("/me/email/confirm", rateLimit("email_change", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.GetConfirmEmailChange)
+		api.POST("/me/email/confirm", rateLimit("email_change", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.PostConfirmEmailChange)
+		api.GET("/me/email/cancel", rateLimit("email_change", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.GetCancelEmailChange)
+		api.POST("/me/email/cancel", rateLimit("email_change", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.PostCancelEmailChange)
+		api.GET("/me/deletion/cancel", rateLimit("account_deletion", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.GetCancelDeletion)
+		api.POST("/me/deletion/cancel", rateLimit("account_deletion", middleware.RouteLimits{IP: ratelimit.PerMinute(20)}), userCtrl.PostCancelDeletion)
 	}
 
 	// --- RUTAS PÚBLICAS DE ADMINISTRACIÓN ---
@@ -61,6 +114,7 @@ func SetupRoutes(r *gin.Engine, app *app.App) {
 	adminPrivate := r.Group("/admin")
 	adminPrivate.Use(middleware.SecurityHeaderMiddleware()) // Prevenir caché y añadir seguridad
 	adminPrivate.Use(middleware.AuthMiddleware(app.TokenManager))
+	adminPrivate.Use(middleware.DenyImpersonation())
 	{
 		adminPrivate.GET("/", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.Dashboard)
 		adminPrivate.POST("/logout", adminCtrl.PostLogout)
@@ -73,7 +127,37 @@ func SetupRoutes(r *gin.Engine, app *app.App) {
 		adminPrivate.POST("/apps/:id", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.UpdateFormApp)
 		adminPrivate.POST("/apps/:id/delete", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT"), adminCtrl.PostDeleteApp)
 
-		// Gestión de Roles
+		// Directorio global de usuarios
+		adminPrivate.GET("/users", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT"), adminCtrl.GetUsersDirectory)
+		users := adminPrivate.Group("/users/:user_id")
+		users.Use(middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT"))
+		{
+			users.GET("", adminCtrl.GetUserDetail)
+			users.POST("/verification", adminCtrl.PostUserResendVerification)
+			users.POST("/password-reset", adminCtrl.PostUserPasswordReset)
+			users.POST("/sessions/revoke", adminCtrl.PostUserRevokeSessions)
+			users.POST("/unlock", adminCtrl.PostUserUnlockAll)
+			users.PUT("/status", adminCtrl.PutUserStatus)
+		}
+		// ROOT o IMPERSONATOR en la app raíz (el servicio vuelve a verificarlo: el middleware deja pasar a ADMIN)
+		adminPrivate.POST("/users/:user_id/impersonate", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", utils.RoleImpersonator), adminCtrl.PostImpersonateUser)
+
+		// Grupos de usuarios: conceden roles por app a todos sus miembros
+		groups := adminPrivate.Group("/groups")
+		groups.Use(middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT"))
+		{
+			groups.GET("", adminCtrl.GetGroups)
+			groups.POST("", adminCtrl.PostGroup)
+			groups.GET("/:group_id", adminCtrl.GetGroupDetail)
+			groups.PUT("/:group_id", adminCtrl.PutGroup)
+			groups.DELETE("/:group_id", adminCtrl.DeleteGroup)
+			groups.POST("/:group_id/members", adminCtrl.PostGroupMembers)
+			groups.DELETE("/:group_id/members/:user_id", adminCtrl.DeleteGroupMember)
+			groups.POST("/:group_id/roles", adminCtrl.PostGroupRole)
+			groups.DELETE("/:group_id/roles", adminCtrl.DeleteGroupRole)
+		}
+
+		// Gestión de Roles globales (los propios de cada app van en /apps/:id/roles)
 		adminPrivate.POST("/roles", adminCtrl.PostRole)
 		adminPrivate.DELETE("/roles", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.DeleteRole)
 
@@ -82,13 +166,25 @@ func SetupRoutes(r *gin.Engine, app *app.App) {
 		{
 			apps.GET("/users", adminCtrl.GetAppUsers)
 			apps.POST("/users", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostUsersInApp)
+			apps.POST("/invitations/:invitation_id/resend", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostResendInvitation)
+			apps.DELETE("/invitations/:invitation_id", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.DeleteInvitation)
+			apps.POST("/users/import", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostImportUsers)
+			apps.GET("/users/export", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.GetExportUsers)
 			apps.DELETE("/users/:user_id", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.RevokeUserAccess)
 			apps.POST("/users/:user_id/unlock", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostUnlockUser)
+			apps.GET("/users/:user_id/attributes", adminCtrl.GetUserAttributes)
+			apps.PATCH("/users/:user_id/attributes", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PatchUserAttributes)
 			apps.GET("/rules", adminCtrl.GetAppRules)
 			apps.POST("/rules", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostDefaultRules)
 			apps.POST("/rules/:code", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostAppRule)
 			apps.PUT("/rules/:code", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PutAppRule)
 			apps.DELETE("/rules/:code", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.DeleteAppRule)
+			apps.POST("/permissions", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostPermission)
+			apps.DELETE("/permissions/:permission_id", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.DeletePermission)
+			apps.POST("/roles", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostAppRole)
+			apps.DELETE("/roles", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.DeleteAppRole)
+			apps.PUT("/roles/:role/permissions", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PutRolePermissions)
+			apps.PUT("/roles/:role/parents", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PutRoleParents)
 			apps.POST("/secret", middleware.RoleMiddleware(app.UarRepo, app.AppRepo, "ROOT", "ADMIN"), adminCtrl.PostRegenerateSecret)
 		}
 	}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDeletionGraceDays         = 30
+	defaultLoginAttemptRetentionDays = 90
+)
+
+// deletionGracePeriod lee ACCOUNT_DELETION_GRACE_DAYS (días hasta ejecutar la baja).
+func deletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultDeletionGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ExportAccount devuelve el archivo con todos los datos del usuario autenticado.
+func (s *userService) ExportAccount(userID uint) (response.AccountExport, error) {
+	export, err := s.accountExportRepo.Export(userID)
+	if err != nil {
+		return response.AccountExport{}, fmt.Errorf("error al exportar los datos: %w", err)
+	}
+	return export, nil
+}
+
+// ScheduleAccountDeletion programa la baja del usuario al final del período de gracia.
+func (s *userService) ScheduleAccountDeletion(userID uint, req request.DeleteAccountRequest) (time.Time, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("usuario no encontrado")
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		return time.Time{}, fmt.Errorf("la contraseña actual es incorrecta")
+	}
+
+	if isRoot, _ := s.uarRepo.HasRole(user.ID, "ROOT"); isRoot {
+		return time.Time{}, fmt.Errorf("un usuario ROOT no puede eliminar su cuenta")
+	}
+
+	if pending, err := s.accountDeletionRepo.FindPendingByUser(user.ID); err == nil {
+		return pending.ScheduledFor, fmt.Errorf("la cuenta ya tiene una baja programada")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, fmt.Errorf("error al verificar bajas previas: %w", err)
+	}
+
+	plainCancel, cancelHash, err := utils.GenerateToken(32)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	deletion := model.AccountDeletion{
+		UserID:          user.ID,
+		ScheduledFor:    time.Now().Add(deletionGracePeriod()),
+		CancelTokenHash: cancelHash,
+	}
+	if err := s.accountDeletionRepo.Create(&deletion); err != nil {
+		return time.Time{}, fmt.Errorf("error al programar la baja: %w", err)
+	}
+
+	go s.emailService.SendAccountDeletionScheduledEmail(user.Email, deletion.ScheduledFor, plainCancel)
+
+	return deletion.ScheduledFor, nil
+}
+
+// CancelAccountDeletion cancela la baja programada del usuario autenticado.
+func (s *userService) CancelAccountDeletion(userID uint) error {
+	deletion, err := s.accountDeletionRepo.FindPendingByUser(userID)
+	if err != nil {
+		return fmt.Errorf("la cuenta no tiene una baja programada")
+	}
+	return s.accountDeletionRepo.Cancel(deletion.ID)
+}
+
+// CancelAccountDeletionByToken cancela la baja desde el link del email.
+func (s *userService) CancelAccountDeletionByToken(token string) error {
+	deletion, err := s.accountDeletionRepo.FindPendingByCancelToken(token)
+	if err != nil {
+		return fmt.Errorf("token inválido o la baja ya no está pendiente")
+	}
+	return s.accountDeletionRepo.Cancel(deletion.ID)
+}
+
+// PurgeDueAccounts ejecuta las bajas vencidas según ACCOUNT_DELETION_MODE:
+// "anonymize" (por defecto) conserva un registro sin datos personales, "hard" borra todo.
+func (s *userService) PurgeDueAccounts() (int, error) {
+	deletions, err := s.accountDeletionRepo.FindDue(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	hard := os.Getenv("ACCOUNT_DELETION_MODE") == "hard"
+	purged := 0
+	for i := range deletions {
+		if hard {
+			err = s.accountDeletionRepo.HardDelete(&deletions[i])
+		} else {
+			err = s.accountDeletionRepo.Anonymize(&deletions[i])
+		}
+		if err != nil {
+			log.Printf("error eliminando la cuenta %d: %v", deletions[i].UserID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// loginAttemptRetention lee LOGIN_ATTEMPT_RETENTION_DAYS (días que se conservan los intentos de login).
+func loginAttemptRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("LOGIN_ATTEMPT_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultLoginAttemptRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PruneLoginActivity borra los intentos de login fuera de la retención y los desafíos canjeados vencidos.
+func (s *userService) PruneLoginActivity() (int64, error) {
+	now := time.Now()
+	if err := s.loginAttemptRepo.DeleteExpiredChallenges(now); err != nil {
+		return 0, err
+	}
+	return s.loginAttemptRepo.DeleteOlderThan(now.Add(-loginAttemptRetention()))
+}
+
+// StartDeletionSweeper ejecuta PurgeDueAccounts y PruneLoginActivity cada `interval`.
+// Bloquea: se lanza como goroutine.
+func (s *userService) StartDeletionSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.PurgeDueAccounts(); err != nil {
+			log.Printf("error en el barrido de bajas de cuenta: %v", err)
+		} else if n > 0 {
+			log.Printf("🗑️ %d cuenta(s) eliminadas por baja vencida", n)
+		}
+		if n, err := s.PruneLoginActivity(); err != nil {
+			log.Printf("error en el barrido de intentos de login: %v", err)
+		} else if n > 0 {
+			log.Printf("🧹 %d intento(s) de login eliminados por retención", n)
+		}
+		<-ticker.C
+	}
+}
@@ -1,12 +1,17 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"peak-auth/model"
 	"peak-auth/repository"
+	"peak-auth/request"
 	"peak-auth/response"
 	"peak-auth/utils"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type ApplicationService interface {
@@ -14,26 +19,37 @@ type ApplicationService interface {
 	UpdateApp(appID string, description string, isActive bool) error
 	ValidateAppNameUnique(name string) error
 	RegenerateSecret(appID string) (string, error)
-	RegisterUserInApp(userEmail, appID, roleName string) error
-	RevokeUserFromApp(userID, appID uint) error
+	RegisterUserInApp(userEmail, appID, roleName string, invitedBy uint, startsAt, expiresAt *time.Time) (bool, error)
+	ListPendingInvitations(appID string) ([]model.Invitation, error)
+	ResendInvitation(appID string, invitationID uint) error
+	RevokeInvitation(appID string, invitationID uint) error
+	GetInvitation(token string) (response.InvitationView, error)
+	AcceptInvitation(req request.AcceptInvitationRequest) error
+	RevokeUserFromApp(userID, appID uint) ([]string, error)
 	GetAppDetails(appID string) (model.Application, error)
 	DeleteApp(appID string) error
 	GetDashboardStats() ([]response.AppStatsResponse, error)
 	GetDashboardStatsForUser(userID uint) ([]response.AppStatsResponse, error)
+	ImportUsers(appID string, rows []request.UserImportRow, opts request.UserImportOptions) (response.UserImportReport, error)
+	ExportUsers(appID string) ([]response.UserExportRow, error)
+	PurgeExpiredGrants() (int, error)
+	StartGrantExpirySweeper(interval time.Duration)
 }
 
 type applicationService struct {
-	repo         repository.ApplicationRepository
-	userRepo     repository.UserRepository
-	roleRepo     repository.RoleRepository
-	uarRepo      repository.UserApplicationRoleRepository
-	txManager    repository.TransactionManager
-	emailService *EmailService
-	passRepo     repository.PasswordResetRepository
+	repo           repository.ApplicationRepository
+	userRepo       repository.UserRepository
+	roleRepo       repository.RoleRepository
+	uarRepo        repository.UserApplicationRoleRepository
+	txManager      repository.TransactionManager
+	emailService   *EmailService
+	passRepo       repository.PasswordResetRepository
+	invitationRepo repository.InvitationRepository
+	ruleService    ApplicationRuleService
 }
 
-func NewApplicationService(repo repository.ApplicationRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository, uarRepo repository.UserApplicationRoleRepository, txManager repository.TransactionManager, emailService *EmailService, passRepo repository.PasswordResetRepository) ApplicationService {
-	return &applicationService{repo: repo, userRepo: userRepo, roleRepo: roleRepo, uarRepo: uarRepo, txManager: txManager, emailService: emailService, passRepo: passRepo}
+func NewApplicationService(repo repository.ApplicationRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository, uarRepo repository.UserApplicationRoleRepository, txManager repository.TransactionManager, emailService *EmailService, passRepo repository.PasswordResetRepository, invitationRepo repository.InvitationRepository, ruleService ApplicationRuleService) ApplicationService {
+	return &applicationService{repo: repo, userRepo: userRepo, roleRepo: roleRepo, uarRepo: uarRepo, txManager: txManager, emailService: emailService, passRepo: passRepo, invitationRepo: invitationRepo, ruleService: ruleService}
 }
 
 func (s *applicationService) CreateApp(name, description string, isActive bool) (model.Application, string, error) {
@@ -75,55 +91,58 @@ func (s *applicationService) ValidateAppNameUnique(name string) error {
 	return nil // No existe, podemos continuar
 }
 
-func (s *applicationService) RegisterUserInApp(userEmail, publicAppID, roleName string) error {
+// RegisterUserInApp asigna el rol a un usuario existente, con una vigencia opcional
+// (`startsAt`/`expiresAt`). Si el email no tiene cuenta, crea (o amplía) una invitación
+// en lugar de dar de alta al usuario. Devuelve true cuando se envió una invitación.
+func (s *applicationService) RegisterUserInApp(userEmail, publicAppID, roleName string, invitedBy uint, startsAt, expiresAt *time.Time) (bool, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return false, fmt.Errorf("el vencimiento del rol debe ser una fecha futura")
+	}
+	if startsAt != nil && expiresAt != nil && !expiresAt.After(*startsAt) {
+		return false, fmt.Errorf("el vencimiento del rol debe ser posterior a su inicio")
+	}
+
 	app, err := s.repo.FindByAppID(publicAppID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	role, err := s.roleRepo.FindByRoleName(roleName)
+	role, err := s.roleRepo.FindByNameForApp(app.ID, roleName)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	return s.txManager.WithinTransaction(func(tx repository.TxRepository) error {
-		user, err := tx.Users().FindByEmail(userEmail)
-		isNewUser := false
-
+	// IMPERSONATOR es global: un ADMIN de la consola podría otorgárselo a sí mismo
+	if role.Name == utils.RoleImpersonator {
+		isRoot, err := s.uarRepo.HasRole(invitedBy, "ROOT")
 		if err != nil {
-			// ESCENARIO 1: Usuario NO existe globalmente. Lo creamos.
-			isNewUser = true
-
-			// Generamos un password aleatorio temporal.
-			// Útil para que la fila en DB sea válida y la cuenta esté 'cerrada'
-			// hasta que el usuario use el link de activación (reset password).
-			placeholderPass, _, _ := utils.GenerateToken(16)
-			hashedPass, _ := utils.HashPassword(placeholderPass)
-
-			user = model.User{
-				Email:      userEmail,
-				Password:   hashedPass,
-				IsVerified: false,
-			}
-
-			profile := model.Profile{
-				FirstName: "Usuario",
-				LastName:  "Invitado",
-			}
+			return false, fmt.Errorf("error al verificar tus permisos: %w", err)
+		}
+		if !isRoot {
+			return false, fmt.Errorf("sólo ROOT puede otorgar el rol %s", utils.RoleImpersonator)
+		}
+	}
 
-			if err := tx.Users().CreateWithProfile(&user, &profile); err != nil {
-				return err
-			}
+	userEmail = utils.NormalizeEmail(userEmail)
+	user, err := s.userRepo.FindByEmail(userEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// La invitación concede roles permanentes al aceptarse: no arrastra la vigencia
+		if startsAt != nil || expiresAt != nil {
+			return false, fmt.Errorf("los roles temporales sólo pueden asignarse a usuarios que ya tienen cuenta")
 		}
+		return true, s.inviteUser(app, userEmail, []model.Role{role}, invitedBy)
+	}
+	if err != nil {
+		return false, err
+	}
 
-		// ESCENARIO 2: Usuario ya existe o acaba de ser creado.
+	return false, s.txManager.WithinTransaction(func(tx repository.TxRepository) error {
 		// Vinculamos el rol en la APP actual.
-		if err := tx.UAR().AssignRole(user.ID, app.ID, role.ID); err != nil {
+		if err := tx.UAR().AssignTimedRole(user.ID, app.ID, role.ID, startsAt, expiresAt); err != nil {
 			return err
 		}
 
-		// ACTIVACIÓN: Si es nuevo o nunca verificó su cuenta, disparamos onboarding.
-		if isNewUser || !user.IsVerified {
+		// ACTIVACIÓN: si nunca verificó su cuenta, disparamos onboarding.
+		if !user.IsVerified {
 			plainToken, hashedToken, _ := utils.GenerateToken(32)
 			reset := model.PasswordReset{
 				UserID:        user.ID,
@@ -142,8 +161,44 @@ func (s *applicationService) RegisterUserInApp(userEmail, publicAppID, roleName
 	})
 }
 
-func (s *applicationService) RevokeUserFromApp(userID, appID uint) error {
-	return s.uarRepo.RevokeAccess(userID, appID)
+// PurgeExpiredGrants elimina los roles temporales vencidos y avisa a cada usuario.
+func (s *applicationService) PurgeExpiredGrants() (int, error) {
+	expired, err := s.uarRepo.PurgeExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, grant := range expired {
+		log.Printf("⏳ rol %s de %s en %s vencido el %s", grant.RoleName, grant.Email, grant.AppName, grant.ExpiresAt.Format(time.RFC3339))
+		go s.emailService.SendRoleExpiredEmail(grant.Email, grant.AppName, grant.RoleName, grant.ExpiresAt)
+	}
+	return len(expired), nil
+}
+
+// StartGrantExpirySweeper ejecuta PurgeExpiredGrants cada `interval`. Bloquea: se lanza como goroutine.
+func (s *applicationService) StartGrantExpirySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.PurgeExpiredGrants(); err != nil {
+			log.Printf("error en el barrido de roles vencidos: %v", err)
+		} else if n > 0 {
+			log.Printf("⏳ %d rol(es) temporales eliminados por vencimiento", n)
+		}
+		<-ticker.C
+	}
+}
+
+// RevokeUserFromApp quita los roles asignados directamente al usuario en la app y devuelve los
+// grupos que le siguen concediendo acceso (sólo ROOT puede sacarlo de ellos).
+func (s *applicationService) RevokeUserFromApp(userID, appID uint) ([]string, error) {
+	if err := s.uarRepo.RevokeAccess(userID, appID); err != nil {
+		return nil, err
+	}
+	groups, err := s.uarRepo.FindGroupsGrantingApp(userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("acceso revocado, pero no se pudieron consultar sus grupos: %w", err)
+	}
+	return groups, nil
 }
 
 func (s *applicationService) GetAppDetails(publicAppID string) (model.Application, error) {
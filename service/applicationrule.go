@@ -1,16 +1,25 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"peak-auth/model"
 	"peak-auth/repository"
 	"peak-auth/request"
 	"peak-auth/utils"
+
+	"gorm.io/gorm"
 )
 
 type ApplicationRuleService interface {
-	ValidateRegistration(appID uint, req request.RegisterRequest) (*utils.RegistrationPolicy, error)
+	ValidateRegistration(app model.Application, req request.RegisterRequest) (*utils.RegistrationPolicy, error)
 	ValidateLogin(appID uint, userID uint) error
+	ValidatePassword(appID uint, password string, userInputs ...string) error
+	FindPasswordPolicy(appID uint) (*utils.PasswordPolicy, error)
+	FindProfilePolicy(appID uint) (utils.ProfilePolicy, error)
+	FindAttributeSchema(appID uint) (utils.AttributeSchema, error)
+	FindLoginPolicy(appID uint) (utils.LoginPolicy, error)
+	FindAuthzPolicy(appID uint) (utils.AuthzPolicy, error)
 	FindRulesByAppID(appID uint) ([]model.ApplicationRules, error)
 	CreateDefaultRules(appID uint) error
 	CreateRule(appID uint, code string, value []byte) error
@@ -30,8 +39,8 @@ func NewApplicationRuleService(ruleRepo repository.ApplicationRuleRepository, ua
 
 // ValidateRegistration valida las reglas de registro de la app y devuelve
 // la política completa (incluyendo DefaultRole y RequireEmailVerification) si alguna regla lo especifica.
-func (s *applicationRuleService) ValidateRegistration(appID uint, req request.RegisterRequest) (*utils.RegistrationPolicy, error) {
-	rules, err := s.ruleRepo.GetRulesByAppID(appID)
+func (s *applicationRuleService) ValidateRegistration(app model.Application, req request.RegisterRequest) (*utils.RegistrationPolicy, error) {
+	rules, err := s.ruleRepo.GetRulesByAppID(app.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +49,7 @@ func (s *applicationRuleService) ValidateRegistration(appID uint, req request.Re
 	for _, rule := range rules {
 		switch rule.Code {
 		case "PWD_POLICY":
-			if err := utils.ValidatePasswordPolicy(rule.Value, req.Password); err != nil {
+			if err := utils.ValidatePasswordPolicy(rule.Value, req.Password, req.Email, req.Username, req.FirstName, req.LastName, app.Name); err != nil {
 				return nil, err
 			}
 		case "REGISTRATION_POLICY":
@@ -90,6 +99,98 @@ func (s *applicationRuleService) ValidateLogin(appID uint, userID uint) error {
 	return nil
 }
 
+// ValidatePassword aplica la PWD_POLICY de la app (si existe) sobre una contraseña en texto plano.
+// userInputs se suman al diccionario del estimador de fortaleza (email, nombre de la app...).
+func (s *applicationRuleService) ValidatePassword(appID uint, password string, userInputs ...string) error {
+	rule, err := s.ruleRepo.GetByCode(appID, "PWD_POLICY")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("error al validar políticas de la aplicación")
+	}
+	return utils.ValidatePasswordPolicy(rule.Value, password, userInputs...)
+}
+
+// FindPasswordPolicy devuelve la PWD_POLICY de la app, o nil si no tiene una activa.
+func (s *applicationRuleService) FindPasswordPolicy(appID uint) (*utils.PasswordPolicy, error) {
+	rule, err := s.ruleRepo.GetByCode(appID, "PWD_POLICY")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return utils.ParsePasswordPolicy(rule.Value)
+}
+
+// FindProfilePolicy devuelve la PROFILE_POLICY de la app. Sin la regla, todos los campos
+// son editables y opcionales.
+func (s *applicationRuleService) FindProfilePolicy(appID uint) (utils.ProfilePolicy, error) {
+	rule, err := s.ruleRepo.GetByCode(appID, "PROFILE_POLICY")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ProfilePolicy{}, nil
+		}
+		return utils.ProfilePolicy{}, err
+	}
+	policy, err := utils.ParseProfilePolicy(rule.Value)
+	if err != nil {
+		return utils.ProfilePolicy{}, err
+	}
+	return *policy, nil
+}
+
+// FindAttributeSchema devuelve la ATTRIBUTE_SCHEMA de la app. Sin la regla, la app no
+// tiene atributos personalizados.
+func (s *applicationRuleService) FindAttributeSchema(appID uint) (utils.AttributeSchema, error) {
+	rule, err := s.ruleRepo.GetByCode(appID, "ATTRIBUTE_SCHEMA")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.AttributeSchema{}, nil
+		}
+		return utils.AttributeSchema{}, err
+	}
+	schema, err := utils.ParseAttributeSchema(rule.Value)
+	if err != nil {
+		return utils.AttributeSchema{}, err
+	}
+	return *schema, nil
+}
+
+// FindLoginPolicy devuelve la LOGIN_POLICY de la app. Sin la regla, sólo se ingresa con email.
+func (s *applicationRuleService) FindLoginPolicy(appID uint) (utils.LoginPolicy, error) {
+	rule, err := s.ruleRepo.GetByCode(appID, "LOGIN_POLICY")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.LoginPolicy{}, nil
+		}
+		return utils.LoginPolicy{}, err
+	}
+	policy, err := utils.ParseLoginPolicy(rule.Value)
+	if err != nil {
+		return utils.LoginPolicy{}, err
+	}
+	return *policy, nil
+}
+
+// FindAuthzPolicy devuelve la AUTHZ_POLICY de la app. Sin la regla, los accesos dependen de
+// los roles y permisos (igual que con enable_roles).
+func (s *applicationRuleService) FindAuthzPolicy(appID uint) (utils.AuthzPolicy, error) {
+	rule, err := s.ruleRepo.GetByCode(appID, "AUTHZ_POLICY")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.AuthzPolicy{EnableRoles: true}, nil
+		}
+		return utils.AuthzPolicy{}, err
+	}
+	policy, err := utils.ParseAuthzPolicy(rule.Value)
+	if err != nil {
+		return utils.AuthzPolicy{}, err
+	}
+	return *policy, nil
+}
+
 func (s *applicationRuleService) FindRulesByAppID(appID uint) ([]model.ApplicationRules, error) {
 	return s.ruleRepo.GetRulesByAppID(appID)
 }
@@ -99,9 +200,28 @@ func (s *applicationRuleService) CreateDefaultRules(appID uint) error {
 }
 
 func (s *applicationRuleService) CreateRule(appID uint, code string, value []byte) error {
+	if err := s.checkDefaultRole(appID, code, value); err != nil {
+		return err
+	}
 	return s.ruleRepo.CreateRule(appID, code, value)
 }
 
+// checkDefaultRole exige que el default_role de REGISTRATION_POLICY sea un rol válido en la
+// app (global o propio): un rol de otra aplicación no se podría asignar al registrarse.
+func (s *applicationRuleService) checkDefaultRole(appID uint, code string, value []byte) error {
+	if code != "REGISTRATION_POLICY" {
+		return nil
+	}
+	policy, err := utils.ParseRegistrationPolicy(value)
+	if err != nil || policy.DefaultRole == "" {
+		return nil
+	}
+	if _, err := s.roleRepo.FindByNameForApp(appID, policy.DefaultRole); err != nil {
+		return fmt.Errorf("el rol por defecto \"%s\" no existe en esta aplicación", policy.DefaultRole)
+	}
+	return nil
+}
+
 func (s *applicationRuleService) UpdateRuleValue(appID uint, code string, value []byte) error {
 	// Protecciones para la App Raíz (ID 1)
 	if appID == 1 {
@@ -122,6 +242,9 @@ func (s *applicationRuleService) UpdateRuleValue(appID uint, code string, value
 		}
 	}
 
+	if err := s.checkDefaultRole(appID, code, value); err != nil {
+		return err
+	}
 	return s.ruleRepo.UpdateRuleValue(appID, code, value)
 }
 
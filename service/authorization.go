@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"peak-auth/repository"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type AuthorizationService interface {
+	Authorize(appID uint, checks []request.AuthorizeCheck) ([]response.AuthorizeDecision, error)
+}
+
+type authorizationService struct {
+	userRepo       repository.UserRepository
+	uarRepo        repository.UserApplicationRoleRepository
+	permissionRepo repository.PermissionRepository
+	ruleService    ApplicationRuleService
+}
+
+func NewAuthorizationService(userRepo repository.UserRepository, uarRepo repository.UserApplicationRoleRepository, permissionRepo repository.PermissionRepository, ruleService ApplicationRuleService) AuthorizationService {
+	return &authorizationService{userRepo: userRepo, uarRepo: uarRepo, permissionRepo: permissionRepo, ruleService: ruleService}
+}
+
+// authzSubject es lo que se sabe de un usuario en la app; se calcula una vez por lote.
+type authzSubject struct {
+	denial string              // motivo por el que no accede a la app ("" si accede)
+	roles  []string            // roles efectivos
+	grants map[string][]string // código de permiso -> roles que lo conceden
+}
+
+// Authorize responde cada consulta con allow/deny y sus motivos. Un usuario accede si está activo,
+// verificado y tiene algún rol efectivo en la app; con la AUTHZ_POLICY en enable_roles además
+// necesita un permiso de sus roles que cubra "resource:action". Un error de la base corta el lote.
+func (s *authorizationService) Authorize(appID uint, checks []request.AuthorizeCheck) ([]response.AuthorizeDecision, error) {
+	policy, err := s.ruleService.FindAuthzPolicy(appID)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la AUTHZ_POLICY: %w", err)
+	}
+
+	subjects := map[string]*authzSubject{}
+	decisions := make([]response.AuthorizeDecision, len(checks))
+	for i, check := range checks {
+		decision := response.AuthorizeDecision{
+			Subject:  check.Subject,
+			Action:   check.Action,
+			Resource: check.Resource,
+			Decision: "deny",
+			Reasons:  []string{},
+		}
+
+		required, err := requiredPermission(check)
+		if err != nil {
+			decisions[i] = denyDecision(decision, err.Error())
+			continue
+		}
+
+		key := strings.TrimSpace(check.Subject)
+		subject, ok := subjects[key]
+		if !ok {
+			if subject, err = s.loadSubject(appID, key); err != nil {
+				return nil, err
+			}
+			subjects[key] = subject
+		}
+		if subject.denial != "" {
+			decisions[i] = denyDecision(decision, subject.denial)
+			continue
+		}
+
+		if !policy.EnableRoles {
+			decisions[i] = allowDecision(decision, "AUTHZ_POLICY sin enable_roles: todo usuario de la aplicación tiene acceso total")
+			continue
+		}
+
+		for _, code := range slices.Sorted(maps.Keys(subject.grants)) {
+			if utils.PermissionMatches(code, required) {
+				decision.Reasons = append(decision.Reasons, fmt.Sprintf("permiso %s concedido por %s", code, strings.Join(subject.grants[code], ", ")))
+			}
+		}
+		if len(decision.Reasons) > 0 {
+			decisions[i] = allowDecision(decision)
+			continue
+		}
+		decisions[i] = denyDecision(decision, fmt.Sprintf("ningún rol del usuario (%s) concede %s", strings.Join(subject.roles, ", "), required))
+	}
+	return decisions, nil
+}
+
+// requiredPermission arma el código "resource:action" que debe cubrir algún permiso del usuario.
+func requiredPermission(check request.AuthorizeCheck) (string, error) {
+	if strings.TrimSpace(check.Subject) == "" || strings.TrimSpace(check.Action) == "" || strings.TrimSpace(check.Resource) == "" {
+		return "", errors.New("subject, action y resource son obligatorios")
+	}
+	required := utils.NormalizePermissionCode(check.Resource) + ":" + utils.NormalizePermissionCode(check.Action)
+	if strings.Contains(required, "*") {
+		return "", errors.New("la acción y el recurso no admiten comodines")
+	}
+	if err := utils.ValidatePermissionCode(required); err != nil {
+		return "", err
+	}
+	return required, nil
+}
+
+// loadSubject carga al usuario (por el "sub" del token) con sus roles efectivos y permisos en la app.
+// La pertenencia a la app se comprueba primero: para cualquier otro ID (inexistente o de otra app)
+// la denegación es la misma, así una app no puede averiguar el estado de usuarios ajenos.
+func (s *authorizationService) loadSubject(appID uint, raw string) (*authzSubject, error) {
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return &authzSubject{denial: "subject inválido: se espera el ID de usuario (claim sub del token)"}, nil
+	}
+
+	const noAccess = "el usuario no tiene acceso a esta aplicación"
+	roles, err := s.uarRepo.FindRolesByUserAndApp(uint(id), appID)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar los roles: %w", err)
+	}
+	if len(roles) == 0 {
+		return &authzSubject{denial: noAccess}, nil
+	}
+
+	user, err := s.userRepo.FindById(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &authzSubject{denial: noAccess}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar el usuario: %w", err)
+	}
+	if !user.IsActive {
+		return &authzSubject{denial: "el usuario está desactivado"}, nil
+	}
+	if !user.IsVerified {
+		return &authzSubject{denial: "el usuario no verificó su email"}, nil
+	}
+
+	subject := &authzSubject{grants: map[string][]string{}}
+	for _, role := range roles {
+		subject.roles = append(subject.roles, role.Name)
+		codes, err := s.permissionRepo.FindCodesByRoles(appID, []uint{role.ID})
+		if err != nil {
+			return nil, fmt.Errorf("error al consultar los permisos: %w", err)
+		}
+		for _, code := range codes {
+			subject.grants[code] = append(subject.grants[code], role.Name)
+		}
+	}
+	return subject, nil
+}
+
+func allowDecision(decision response.AuthorizeDecision, reasons ...string) response.AuthorizeDecision {
+	decision.Allowed = true
+	decision.Decision = "allow"
+	decision.Reasons = append(decision.Reasons, reasons...)
+	return decision
+}
+
+func denyDecision(decision response.AuthorizeDecision, reason string) response.AuthorizeDecision {
+	decision.Reasons = append(decision.Reasons, reason)
+	return decision
+}
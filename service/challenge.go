@@ -0,0 +1,151 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strings"
+	"time"
+)
+
+// ErrChallengeRequired indica que el login debe acompañarse de un desafío resuelto.
+var ErrChallengeRequired = errors.New("se requiere resolver un desafío antes de iniciar sesión")
+
+const (
+	challengeWindow            = 15 * time.Minute
+	challengeTTL               = 5 * time.Minute
+	defaultChallengeDifficulty = 18
+)
+
+// challengeScope ata el desafío a la app y al identificador (email o username) para que no
+// pueda reutilizarse en otra cuenta.
+func challengeScope(publicAppID, identifier string) string {
+	return publicAppID + ":" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+func challengeDifficulty(policy utils.SessionPolicy) int {
+	if policy.ChallengeDifficulty > 0 {
+		return policy.ChallengeDifficulty
+	}
+	return defaultChallengeDifficulty
+}
+
+// IssueLoginChallenge emite un desafío para el próximo login de `identifier` (email o username)
+// en la app. Si la app exige CAPTCHA y hay un proveedor configurado, devuelve la site key del widget.
+func (s *userService) IssueLoginChallenge(publicAppID, identifier string) (response.ChallengeResponse, error) {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.ChallengeResponse{}, fmt.Errorf("aplicación no encontrada")
+	}
+	policy := s.sessionPolicy(app.ID, 24*60)
+
+	if policy.ChallengeType == "captcha" && s.captchaVerifier != nil {
+		return response.ChallengeResponse{Type: "captcha", SiteKey: os.Getenv("CAPTCHA_SITE_KEY")}, nil
+	}
+
+	challenge, err := s.challengeManager.Issue(challengeScope(app.AppID, identifier), challengeDifficulty(policy), challengeTTL)
+	if err != nil {
+		return response.ChallengeResponse{}, fmt.Errorf("error al generar el desafío: %w", err)
+	}
+	return response.ChallengeResponse{
+		Type:       "pow",
+		Token:      challenge.Token,
+		Difficulty: challenge.Difficulty,
+		Algorithm:  challenge.Algorithm,
+		ExpiresAt:  challenge.ExpiresAt,
+	}, nil
+}
+
+// challengeRequired evalúa la SESSION_POLICY contra los fallos recientes con el mismo
+// identificador en la app y los fallos recientes desde la IP del cliente. Se cuenta por
+// identificador (no por cuenta) para que la respuesta no revele si el email está registrado.
+func (s *userService) challengeRequired(policy utils.SessionPolicy, identifier string, appID uint, ip string) bool {
+	identifier = truncate(identifier, loginIdentifierMaxLength)
+	if policy.ChallengeAfterFailures > 0 && identifier != "" {
+		count, err := s.loginAttemptRepo.CountFailuresByIdentifier(appID, identifier, time.Now().Add(-challengeWindow))
+		if err == nil && count >= int64(policy.ChallengeAfterFailures) {
+			return true
+		}
+	}
+	if policy.ChallengeAfterIPFailures > 0 && ip != "" {
+		count, err := s.loginAttemptRepo.CountFailuresByIP(ip, time.Now().Add(-challengeWindow))
+		if err == nil && count >= int64(policy.ChallengeAfterIPFailures) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyLoginChallenge exige y valida el desafío cuando la política lo requiere.
+func (s *userService) verifyLoginChallenge(req request.LoginRequest, publicAppID string, appID uint, policy utils.SessionPolicy) error {
+	if !s.challengeRequired(policy, req.Identifier(), appID, req.ClientIP) {
+		return nil
+	}
+
+	switch {
+	case req.ChallengeToken != "":
+		if err := s.challengeManager.Verify(req.ChallengeToken, req.ChallengeSolution, challengeScope(publicAppID, req.Identifier()), challengeDifficulty(policy)); err != nil {
+			return fmt.Errorf("%w: %v", ErrChallengeRequired, err)
+		}
+		// Un desafío resuelto vale para un único intento
+		sum := sha256.Sum256([]byte(req.ChallengeToken))
+		first, err := s.loginAttemptRepo.ConsumeChallenge(hex.EncodeToString(sum[:]), time.Now().Add(challengeTTL))
+		if err != nil {
+			return fmt.Errorf("%w: no se pudo validar el desafío", ErrChallengeRequired)
+		}
+		if !first {
+			return fmt.Errorf("%w: el desafío ya fue utilizado", ErrChallengeRequired)
+		}
+		return nil
+	case req.CaptchaToken != "" && s.captchaVerifier != nil:
+		ok, err := s.captchaVerifier.Verify(req.CaptchaToken, req.ClientIP)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrChallengeRequired, err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: CAPTCHA inválido", ErrChallengeRequired)
+		}
+		return nil
+	}
+	return ErrChallengeRequired
+}
+
+// loginIdentifierMaxLength es el largo de la columna login_attempts.email.
+const loginIdentifierMaxLength = 100
+
+// recordLoginAttempt guarda el intento para el conteo por IP y la auditoría del usuario.
+// Si no se puede guardar, el fallo no sumaría para el desafío: el llamador debe rechazar el login.
+func (s *userService) recordLoginAttempt(userID *uint, appID uint, req request.LoginRequest, success bool, reason string) error {
+	err := s.loginAttemptRepo.Create(&model.LoginAttempt{
+		UserID:        userID,
+		ApplicationID: appID,
+		Email:         truncate(req.Identifier(), loginIdentifierMaxLength),
+		IP:            truncate(req.ClientIP, 64),
+		UserAgent:     truncate(req.UserAgent, 255),
+		Success:       success,
+		Reason:        reason,
+	})
+	if err != nil {
+		log.Printf("login: no se pudo registrar el intento en la app %d: %v", appID, err)
+		return fmt.Errorf("no se pudo registrar el intento de inicio de sesión")
+	}
+	return nil
+}
+
+// truncate deja `s` en a lo sumo `max` caracteres válidos para Postgres: descarta los bytes
+// UTF-8 inválidos y los NUL, y corta por runa (varchar cuenta caracteres, no bytes).
+func truncate(s string, max int) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
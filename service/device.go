@@ -0,0 +1,92 @@
+package service
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionDevice es el resultado de reconocer el dispositivo de un login.
+type sessionDevice struct {
+	DeviceID    *uint
+	RevokeToken string
+	RevokeHash  []byte
+}
+
+// recognizeDevice busca el dispositivo del login entre los conocidos del usuario y lo registra si es nuevo.
+// Sólo devuelve un token de revocación cuando hay que avisar: el primer dispositivo de la cuenta
+// se registra en silencio para no notificar el alta. Si el dispositivo no se puede buscar o guardar
+// se avisa igual (sin DeviceID): un error no debe ocultarle el aviso al titular de la cuenta.
+func (s *userService) recognizeDevice(userID uint, req request.LoginRequest) sessionDevice {
+	fingerprint := utils.DeviceFingerprint(req.UserAgent, req.ClientIP)
+
+	device, err := s.deviceRepo.FindByFingerprint(userID, fingerprint)
+	if err == nil {
+		_ = s.deviceRepo.Touch(device.ID, req.ClientIP)
+		return sessionDevice{DeviceID: &device.ID}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("dispositivos: no se pudo buscar el dispositivo del usuario %d: %v", userID, err)
+		return newDeviceNotice(nil)
+	}
+
+	known, err := s.deviceRepo.CountByUser(userID)
+	if err != nil {
+		log.Printf("dispositivos: no se pudieron contar los dispositivos del usuario %d: %v", userID, err)
+		known = 1
+	}
+
+	device = model.UserDevice{
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		UserAgent:   truncate(req.UserAgent, 255),
+		IPPrefix:    truncate(utils.IPPrefix(req.ClientIP), 64),
+		LastIP:      truncate(req.ClientIP, 64),
+		LastSeenAt:  time.Now(),
+	}
+	if err := s.deviceRepo.Create(&device); err != nil {
+		log.Printf("dispositivos: no se pudo registrar el dispositivo del usuario %d: %v", userID, err)
+		return newDeviceNotice(nil)
+	}
+
+	if known == 0 {
+		return sessionDevice{DeviceID: &device.ID}
+	}
+	return newDeviceNotice(&device.ID)
+}
+
+// newDeviceNotice arma el resultado de un dispositivo nuevo con el token de revocación del aviso.
+func newDeviceNotice(deviceID *uint) sessionDevice {
+	result := sessionDevice{DeviceID: deviceID}
+	plain, hash, err := utils.GenerateToken(32)
+	if err == nil {
+		result.RevokeToken = plain
+		result.RevokeHash = hash
+	}
+	return result
+}
+
+// RevokeSession cierra la sesión iniciada desde un dispositivo nuevo usando el link del email,
+// invalida su access token y olvida el dispositivo para que un nuevo login desde él vuelva a notificarse.
+func (s *userService) RevokeSession(token string) error {
+	hash := sha256.Sum256([]byte(token))
+	rt, err := s.refreshTokenRepo.FindByRevokeHash(hash[:])
+	if err != nil {
+		return fmt.Errorf("link inválido o sesión ya cerrada")
+	}
+
+	if err := s.refreshTokenRepo.RevokeSession(rt, time.Now()); err != nil {
+		return fmt.Errorf("error al cerrar la sesión: %w", err)
+	}
+	if rt.UserDeviceID != nil {
+		_ = s.deviceRepo.Delete(*rt.UserDeviceID)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"peak-auth/model"
+	"peak-auth/repository"
+	"peak-auth/request"
+	"peak-auth/utils"
+	"strings"
+	"time"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+// RequestEmailChange inicia el cambio de email del usuario autenticado: envía el link de
+// confirmación a la nueva dirección y un aviso con link de cancelación a la actual.
+func (s *userService) RequestEmailChange(userID uint, publicAppID string, req request.ChangeEmailRequest) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado")
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		return fmt.Errorf("la contraseña actual es incorrecta")
+	}
+
+	newEmail := utils.NormalizeEmail(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return fmt.Errorf("el nuevo email debe ser distinto al actual")
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(newEmail)
+	if err != nil {
+		return fmt.Errorf("error verificando email: %w", err)
+	}
+	if exists {
+		return repository.ErrEmailTaken
+	}
+
+	var appID uint
+	if app, err := s.appRepo.FindByAppID(publicAppID); err == nil {
+		appID = app.ID
+	}
+
+	plainToken, tokenHash, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+	plainCancel, cancelHash, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Sólo puede haber una solicitud vigente por usuario
+	if err := s.emailChangeRepo.CancelPendingByUser(user.ID); err != nil {
+		return fmt.Errorf("error al invalidar solicitudes previas: %w", err)
+	}
+
+	change := model.EmailChange{
+		UserID:          user.ID,
+		ApplicationID:   appID,
+		OldEmail:        user.Email,
+		NewEmail:        newEmail,
+		TokenHash:       tokenHash,
+		CancelTokenHash: cancelHash,
+		ExpiresAt:       time.Now().Add(emailChangeTTL),
+	}
+	if err := s.emailChangeRepo.Create(&change); err != nil {
+		return fmt.Errorf("error al registrar la solicitud: %w", err)
+	}
+
+	if err := s.emailService.SendEmailChangeConfirmation(newEmail, plainToken); err != nil {
+		return fmt.Errorf("error enviando email: %v", err)
+	}
+	go s.emailService.SendEmailChangeNotice(user.Email, newEmail, plainCancel)
+
+	return nil
+}
+
+// ConfirmEmailChange aplica el cambio confirmado desde la nueva dirección y revoca las sesiones.
+func (s *userService) ConfirmEmailChange(token string) error {
+	change, err := s.emailChangeRepo.FindPendingByToken(token)
+	if err != nil {
+		return fmt.Errorf("token inválido o expirado")
+	}
+
+	if err := s.emailChangeRepo.Confirm(change); err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) || errors.Is(err, repository.ErrEmailChangeNotPending) {
+			return err
+		}
+		return fmt.Errorf("error al cambiar el email: %w", err)
+	}
+
+	go s.emailService.SendSecurityNotificationEmail(change.OldEmail, SecurityEventEmailChanged)
+	return nil
+}
+
+// CancelEmailChange cancela la solicitud desde el link enviado a la dirección anterior.
+func (s *userService) CancelEmailChange(token string) error {
+	change, err := s.emailChangeRepo.FindPendingByCancelToken(token)
+	if err != nil {
+		return fmt.Errorf("token inválido o expirado")
+	}
+	return s.emailChangeRepo.Cancel(change.ID)
+}
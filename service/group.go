@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"peak-auth/model"
+	"peak-auth/repository"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Máximo de usuarios por alta masiva en un grupo.
+const groupMembersBatchLimit = 500
+
+type GroupService interface {
+	ListGroups() ([]response.GroupRow, error)
+	GetGroup(groupID uint) (response.GroupDetail, error)
+	FindByUser(userID uint) ([]model.Group, error)
+	CreateGroup(req request.GroupRequest) (model.Group, error)
+	UpdateGroup(groupID uint, req request.GroupRequest) error
+	DeleteGroup(groupID uint) error
+	AddMembers(groupID uint, req request.GroupMembersRequest) (response.GroupMembersResult, error)
+	RemoveMember(groupID, userID uint) error
+	GrantRole(groupID uint, req request.GroupRoleRequest) error
+	RevokeRole(groupID uint, req request.GroupRoleRequest) error
+}
+
+type groupService struct {
+	groupRepo repository.GroupRepository
+	userRepo  repository.UserRepository
+	appRepo   repository.ApplicationRepository
+	roleRepo  repository.RoleRepository
+}
+
+func NewGroupService(groupRepo repository.GroupRepository, userRepo repository.UserRepository, appRepo repository.ApplicationRepository, roleRepo repository.RoleRepository) GroupService {
+	return &groupService{groupRepo: groupRepo, userRepo: userRepo, appRepo: appRepo, roleRepo: roleRepo}
+}
+
+func (s *groupService) ListGroups() ([]response.GroupRow, error) {
+	return s.groupRepo.FindAll()
+}
+
+// GetGroup arma la ficha del grupo con sus miembros y los roles concedidos.
+func (s *groupService) GetGroup(groupID uint) (response.GroupDetail, error) {
+	group, err := s.findGroup(groupID)
+	if err != nil {
+		return response.GroupDetail{}, err
+	}
+
+	detail := response.GroupDetail{Group: group}
+	if detail.Members, err = s.groupRepo.FindMembers(group.ID); err != nil {
+		return detail, fmt.Errorf("error al cargar los miembros: %w", err)
+	}
+	if detail.Grants, err = s.groupRepo.FindGrants(group.ID); err != nil {
+		return detail, fmt.Errorf("error al cargar los roles del grupo: %w", err)
+	}
+	return detail, nil
+}
+
+func (s *groupService) FindByUser(userID uint) ([]model.Group, error) {
+	return s.groupRepo.FindByUser(userID)
+}
+
+func (s *groupService) CreateGroup(req request.GroupRequest) (model.Group, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return model.Group{}, errors.New("el nombre del grupo es obligatorio")
+	}
+	if _, err := s.groupRepo.FindByName(name); err == nil {
+		return model.Group{}, fmt.Errorf("ya existe un grupo \"%s\"", name)
+	}
+
+	group := model.Group{Name: name, Description: strings.TrimSpace(req.Description)}
+	if err := s.groupRepo.Create(&group); err != nil {
+		return model.Group{}, fmt.Errorf("error al crear el grupo: %w", err)
+	}
+	return group, nil
+}
+
+func (s *groupService) UpdateGroup(groupID uint, req request.GroupRequest) error {
+	group, err := s.findGroup(groupID)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("el nombre del grupo es obligatorio")
+	}
+	if existing, err := s.groupRepo.FindByName(name); err == nil && existing.ID != group.ID {
+		return fmt.Errorf("ya existe un grupo \"%s\"", name)
+	}
+
+	group.Name = name
+	group.Description = strings.TrimSpace(req.Description)
+	return s.groupRepo.Update(&group)
+}
+
+// DeleteGroup elimina el grupo: sus miembros pierden los roles que recibían a través de él.
+func (s *groupService) DeleteGroup(groupID uint) error {
+	group, err := s.findGroup(groupID)
+	if err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(group)
+}
+
+// AddMembers agrega usuarios al grupo por ID y/o email. Los que ya eran miembros se omiten y los
+// que no existen se informan sin cortar el alta del resto.
+func (s *groupService) AddMembers(groupID uint, req request.GroupMembersRequest) (response.GroupMembersResult, error) {
+	result := response.GroupMembersResult{NotFound: []string{}}
+	group, err := s.findGroup(groupID)
+	if err != nil {
+		return result, err
+	}
+
+	if len(req.UserIDs)+len(req.Emails) == 0 {
+		return result, errors.New("indica al menos un usuario")
+	}
+	if len(req.UserIDs)+len(req.Emails) > groupMembersBatchLimit {
+		return result, fmt.Errorf("se pueden agregar hasta %d usuarios por vez", groupMembersBatchLimit)
+	}
+
+	seen := map[uint]bool{}
+	var userIDs []uint
+	add := func(user model.User) {
+		if !seen[user.ID] {
+			seen[user.ID] = true
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+	for _, id := range req.UserIDs {
+		user, err := s.userRepo.FindById(id)
+		if err != nil {
+			result.NotFound = append(result.NotFound, fmt.Sprintf("#%d", id))
+			continue
+		}
+		add(user)
+	}
+	for _, email := range req.Emails {
+		if email = strings.TrimSpace(email); email == "" {
+			continue
+		}
+		user, err := s.userRepo.FindByEmail(email)
+		if err != nil {
+			result.NotFound = append(result.NotFound, email)
+			continue
+		}
+		add(user)
+	}
+
+	added, err := s.groupRepo.AddMembers(group.ID, userIDs)
+	if err != nil {
+		return result, fmt.Errorf("error al agregar los miembros: %w", err)
+	}
+	result.Added = added
+	result.Skipped = int64(len(userIDs)) - added
+	return result, nil
+}
+
+func (s *groupService) RemoveMember(groupID, userID uint) error {
+	group, err := s.findGroup(groupID)
+	if err != nil {
+		return err
+	}
+	removed, err := s.groupRepo.RemoveMember(group.ID, userID)
+	if err != nil {
+		return fmt.Errorf("error al quitar el miembro: %w", err)
+	}
+	if removed == 0 {
+		return errors.New("el usuario no pertenece al grupo")
+	}
+	return nil
+}
+
+// GrantRole concede al grupo un rol (global o propio) en la aplicación.
+func (s *groupService) GrantRole(groupID uint, req request.GroupRoleRequest) error {
+	group, app, role, err := s.resolveGrant(groupID, req)
+	if err != nil {
+		return err
+	}
+	// Los accesos a la consola se asignan usuario por usuario
+	if app.AppID == utils.AppID_PEAK_AUTH {
+		return errors.New("los roles de la consola de administración no se conceden por grupo")
+	}
+	return s.groupRepo.AddGrant(group.ID, app.ID, role.ID)
+}
+
+func (s *groupService) RevokeRole(groupID uint, req request.GroupRoleRequest) error {
+	group, app, role, err := s.resolveGrant(groupID, req)
+	if err != nil {
+		return err
+	}
+	removed, err := s.groupRepo.RemoveGrant(group.ID, app.ID, role.ID)
+	if err != nil {
+		return fmt.Errorf("error al quitar el rol: %w", err)
+	}
+	if removed == 0 {
+		return errors.New("el grupo no tiene ese rol en la aplicación")
+	}
+	return nil
+}
+
+func (s *groupService) findGroup(groupID uint) (model.Group, error) {
+	group, err := s.groupRepo.FindByID(groupID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return group, errors.New("grupo no encontrado")
+	}
+	return group, err
+}
+
+func (s *groupService) resolveGrant(groupID uint, req request.GroupRoleRequest) (model.Group, model.Application, model.Role, error) {
+	group, err := s.findGroup(groupID)
+	if err != nil {
+		return group, model.Application{}, model.Role{}, err
+	}
+	app, err := s.appRepo.FindByAppID(req.AppID)
+	if err != nil {
+		return group, app, model.Role{}, errors.New("aplicación no encontrada")
+	}
+	role, err := s.roleRepo.FindByNameForApp(app.ID, strings.ToUpper(strings.TrimSpace(req.Role)))
+	if err != nil {
+		return group, app, role, fmt.Errorf("el rol \"%s\" no existe en esta aplicación", strings.ToUpper(req.Role))
+	}
+	return group, app, role, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"peak-auth/auth"
+	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Duración fija del access token de suplantación: no se renueva ni depende de la SESSION_POLICY.
+const impersonationTokenDuration = 15 * time.Minute
+
+const userDetailImpersonations = 10
+
+// StartImpersonation emite un access token de corta duración para actuar como el usuario en
+// la aplicación indicada. Sólo ROOT o quien tenga el rol IMPERSONATOR en la app raíz puede
+// hacerlo; el token lleva el claim "act" con el admin, queda registrado y se avisa al usuario.
+func (s *userService) StartImpersonation(actorID, userID uint, req request.ImpersonationRequest) (response.ImpersonationToken, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return response.ImpersonationToken{}, fmt.Errorf("indica el motivo de la suplantación")
+	}
+	if actorID == userID {
+		return response.ImpersonationToken{}, fmt.Errorf("no puedes suplantarte a ti mismo")
+	}
+
+	peakApp, err := s.appRepo.FindByAppID(utils.AppID_PEAK_AUTH)
+	if err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("no se encontró la aplicación raíz")
+	}
+
+	// 1. El actor necesita un permiso explícito: ADMIN de la consola no alcanza
+	actorRoles, err := s.uarRepo.GetUserRolesInApp(actorID, peakApp.ID)
+	if err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("error al verificar tus permisos: %w", err)
+	}
+	if !slices.Contains(actorRoles, "ROOT") && !slices.Contains(actorRoles, utils.RoleImpersonator) {
+		return response.ImpersonationToken{}, fmt.Errorf("se requiere rol ROOT o %s para suplantar usuarios", utils.RoleImpersonator)
+	}
+	actor, err := s.userRepo.FindById(actorID)
+	if err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("administrador no encontrado")
+	}
+
+	// 2. Aplicación destino: nunca la consola
+	app, err := s.appRepo.FindByAppID(req.AppID)
+	if err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("aplicación no encontrada")
+	}
+	if app.ID == peakApp.ID {
+		return response.ImpersonationToken{}, fmt.Errorf("no se puede suplantar a un usuario en la consola de administración")
+	}
+	if !app.IsActive {
+		return response.ImpersonationToken{}, fmt.Errorf("la aplicación está desactivada")
+	}
+
+	// 3. Usuario destino: activo, con acceso a la app y sin permisos en la consola
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("usuario no encontrado")
+	}
+	if !user.IsActive {
+		return response.ImpersonationToken{}, fmt.Errorf("usuario está desactivado")
+	}
+	if consoleRoles, _ := s.uarRepo.GetUserRolesInApp(user.ID, peakApp.ID); len(consoleRoles) > 0 {
+		return response.ImpersonationToken{}, fmt.Errorf("no se puede suplantar a un administrador de la consola")
+	}
+	roleModels, err := s.uarRepo.FindRolesByUserAndApp(user.ID, app.ID)
+	if err != nil || len(roleModels) == 0 {
+		return response.ImpersonationToken{}, fmt.Errorf("el usuario no tiene acceso a esta aplicación")
+	}
+	roles := make([]string, len(roleModels))
+	for i, r := range roleModels {
+		roles[i] = r.Name
+	}
+
+	// 4. Token con el actor (RFC 8693) y sin refresh token
+	extras := s.tokenExtras(user.ID, app.ID, roleModels)
+	extras.Actor = &auth.ActorClaim{Subject: fmt.Sprintf("%d", actor.ID), Username: actor.Email}
+	duration := s.tokenDuration(user.ID, app.ID, impersonationTokenDuration)
+	expiresAt := time.Now().Add(duration)
+	token, err := s.tokenManager.GenerateTokenWithExtras(user.ID, user.Email, app.AppID, roles, extras, duration)
+	if err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("error al generar el token: %w", err)
+	}
+
+	// 5. Auditoría: sin registro no se entrega el token
+	entry := model.ImpersonationLog{
+		ActorID:       actor.ID,
+		UserID:        user.ID,
+		ApplicationID: app.ID,
+		Reason:        reason,
+		IP:            req.ClientIP,
+		UserAgent:     truncate(req.UserAgent, 255),
+		ExpiresAt:     expiresAt,
+	}
+	if err := s.impersonationRepo.Create(&entry); err != nil {
+		return response.ImpersonationToken{}, fmt.Errorf("error al registrar la suplantación: %w", err)
+	}
+	log.Printf("suplantación: admin %d (%s) actúa como usuario %d en %s hasta %s: %s", actor.ID, actor.Email, user.ID, app.AppID, expiresAt.Format(time.RFC3339), reason)
+
+	go func() {
+		if err := s.emailService.SendImpersonationEmail(user.Email, app.Name, reason, expiresAt); err != nil {
+			log.Printf("no se pudo avisar de la suplantación al usuario %d: %v", user.ID, err)
+		}
+	}()
+
+	return response.ImpersonationToken{AccessToken: token, AppID: app.AppID, ExpiresAt: expiresAt}, nil
+}
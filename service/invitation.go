@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"peak-auth/model"
+	"peak-auth/repository"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// invitationTTL es la vigencia del link de cada envío de una invitación.
+const invitationTTL = 7 * 24 * time.Hour
+
+// inviteUser crea la invitación del email a la app, o si ya hay una pendiente le suma
+// los roles que le falten y la reenvía con un link nuevo.
+func (s *applicationService) inviteUser(app model.Application, email string, roles []model.Role, invitedBy uint) error {
+	existing, err := s.invitationRepo.FindPendingByAppAndEmail(app.ID, email)
+	if err == nil {
+		for _, role := range roles {
+			hasRole := false
+			for _, r := range existing.Roles {
+				hasRole = hasRole || r.ID == role.ID
+			}
+			if hasRole {
+				continue
+			}
+			if err := s.invitationRepo.AddRole(existing, &role); err != nil {
+				return fmt.Errorf("error al actualizar la invitación: %w", err)
+			}
+		}
+		existing.Application = app
+		return s.sendInvitation(existing)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("error al buscar invitaciones previas: %w", err)
+	}
+
+	plainToken, tokenHash, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	invitation := model.Invitation{
+		ApplicationID: app.ID,
+		Email:         email,
+		Roles:         roles,
+		Status:        model.InvitationPending,
+		TokenHash:     tokenHash,
+		ExpiresAt:     time.Now().Add(invitationTTL),
+		SendCount:     1,
+		LastSentAt:    time.Now(),
+	}
+	if invitedBy != 0 {
+		invitation.InvitedByID = &invitedBy
+	}
+	if err := s.invitationRepo.Create(&invitation); err != nil {
+		return fmt.Errorf("error al crear la invitación: %w", err)
+	}
+
+	go s.emailService.SendInvitationEmail(email, app.Name, invitation.ExpiresAt, plainToken)
+	return nil
+}
+
+// sendInvitation genera un link nuevo (invalidando el anterior) y reenvía la invitación.
+func (s *applicationService) sendInvitation(invitation *model.Invitation) error {
+	plainToken, tokenHash, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(invitationTTL)
+	if err := s.invitationRepo.Renew(invitation.ID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("error al renovar la invitación: %w", err)
+	}
+
+	go s.emailService.SendInvitationEmail(invitation.Email, invitation.Application.Name, expiresAt, plainToken)
+	return nil
+}
+
+// ListPendingInvitations devuelve las invitaciones sin aceptar ni revocar de la app.
+func (s *applicationService) ListPendingInvitations(publicAppID string) ([]model.Invitation, error) {
+	app, err := s.repo.FindByAppID(publicAppID)
+	if err != nil {
+		return nil, fmt.Errorf("aplicación no encontrada")
+	}
+	return s.invitationRepo.FindPendingByApp(app.ID)
+}
+
+// findAppInvitation busca una invitación pendiente de la app indicada.
+func (s *applicationService) findAppInvitation(publicAppID string, invitationID uint) (*model.Invitation, error) {
+	app, err := s.repo.FindByAppID(publicAppID)
+	if err != nil {
+		return nil, fmt.Errorf("aplicación no encontrada")
+	}
+
+	invitation, err := s.invitationRepo.FindByIDAndApp(invitationID, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invitación no encontrada")
+	}
+	if invitation.Status != model.InvitationPending {
+		return nil, repository.ErrInvitationNotPending
+	}
+	return invitation, nil
+}
+
+// ResendInvitation reenvía una invitación pendiente (aunque haya vencido) con un link nuevo.
+func (s *applicationService) ResendInvitation(publicAppID string, invitationID uint) error {
+	invitation, err := s.findAppInvitation(publicAppID, invitationID)
+	if err != nil {
+		return err
+	}
+	return s.sendInvitation(invitation)
+}
+
+// RevokeInvitation anula una invitación pendiente: su link deja de funcionar.
+func (s *applicationService) RevokeInvitation(publicAppID string, invitationID uint) error {
+	invitation, err := s.findAppInvitation(publicAppID, invitationID)
+	if err != nil {
+		return err
+	}
+	return s.invitationRepo.Revoke(invitation.ID)
+}
+
+// GetInvitation devuelve los datos de una invitación vigente para la página de aceptación.
+func (s *applicationService) GetInvitation(token string) (response.InvitationView, error) {
+	invitation, err := s.invitationRepo.FindPendingByToken(token)
+	if err != nil {
+		return response.InvitationView{}, fmt.Errorf("la invitación no existe, ya fue usada o venció")
+	}
+
+	user, err := s.userRepo.FindByEmail(invitation.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return response.InvitationView{}, fmt.Errorf("error al verificar la cuenta: %w", err)
+	}
+	hasAccount := err == nil
+
+	view := response.InvitationView{
+		Email:       invitation.Email,
+		AppName:     invitation.Application.Name,
+		ExpiresAt:   invitation.ExpiresAt,
+		HasAccount:  hasAccount,
+		SetPassword: hasAccount && !user.IsVerified,
+	}
+	for _, role := range invitation.Roles {
+		view.Roles = append(view.Roles, role.Name)
+	}
+	return view, nil
+}
+
+// AcceptInvitation crea la cuenta del invitado con su nombre, contraseña (validada con la
+// PWD_POLICY de la app) y username opcional, y le asigna los roles. El email queda verificado porque el link
+// llegó a esa casilla. Si el email ya tenía una cuenta verificada, sólo se le suman los roles;
+// si nunca se verificó (cualquiera pudo registrarla con ese email), el invitado elige una
+// contraseña nueva que reemplaza a la anterior y cierra sus sesiones.
+func (s *applicationService) AcceptInvitation(req request.AcceptInvitationRequest) error {
+	invitation, err := s.invitationRepo.FindPendingByToken(req.Token)
+	if err != nil {
+		return fmt.Errorf("la invitación no existe, ya fue usada o venció")
+	}
+
+	user, err := s.userRepo.FindByEmail(invitation.Email)
+	switch {
+	case err == nil && user.IsVerified:
+		err = s.invitationRepo.Accept(invitation, &user, nil)
+	case err == nil:
+		var firstName, lastName string
+		if withProfile, findErr := s.userRepo.FindById(user.ID); findErr == nil {
+			firstName, lastName = withProfile.Profile.FirstName, withProfile.Profile.LastName
+		}
+		hashed, pwdErr := s.invitationPassword(invitation, req, firstName, lastName)
+		if pwdErr != nil {
+			return pwdErr
+		}
+		// El username anterior lo eligió quien registró la cuenta: se reemplaza o se borra
+		username, nameErr := s.invitationUsername(req)
+		if nameErr != nil {
+			return nameErr
+		}
+		err = s.invitationRepo.AcceptClaimingAccount(invitation, user.ID, hashed, username)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		firstName := strings.TrimSpace(req.FirstName)
+		lastName := strings.TrimSpace(req.LastName)
+		if firstName == "" || lastName == "" {
+			return fmt.Errorf("nombre y apellido son requeridos")
+		}
+		hashed, pwdErr := s.invitationPassword(invitation, req, firstName, lastName)
+		if pwdErr != nil {
+			return pwdErr
+		}
+		username, nameErr := s.invitationUsername(req)
+		if nameErr != nil {
+			return nameErr
+		}
+		user = model.User{Email: invitation.Email, Username: username, Password: hashed, IsVerified: true}
+		profile := model.Profile{FirstName: firstName, LastName: lastName}
+		err = s.invitationRepo.Accept(invitation, &user, &profile)
+	default:
+		return fmt.Errorf("error al buscar la cuenta: %w", err)
+	}
+
+	if errors.Is(err, repository.ErrInvitationNotPending) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error al aceptar la invitación: %w", err)
+	}
+	return nil
+}
+
+// invitationUsername valida el username opcional elegido al aceptar; vacío es nil.
+func (s *applicationService) invitationUsername(req request.AcceptInvitationRequest) (*string, error) {
+	if strings.TrimSpace(req.Username) == "" {
+		return nil, nil
+	}
+	username, err := checkUsername(s.userRepo, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &username, nil
+}
+
+// invitationPassword valida la contraseña elegida al aceptar (PWD_POLICY de la app) y la hashea.
+func (s *applicationService) invitationPassword(invitation *model.Invitation, req request.AcceptInvitationRequest, firstName, lastName string) (string, error) {
+	// Mismo mínimo que el registro, por si la app no tiene PWD_POLICY
+	if len(req.Password) < 6 {
+		return "", fmt.Errorf("la contraseña debe tener al menos 6 caracteres")
+	}
+	if req.Password != req.ConfirmPassword {
+		return "", fmt.Errorf("las contraseñas no coinciden")
+	}
+	if err := s.ruleService.ValidatePassword(invitation.ApplicationID, req.Password, invitation.Email, firstName, lastName, invitation.Application.Name); err != nil {
+		return "", err
+	}
+
+	hashed, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return "", fmt.Errorf("error al hashear contraseña: %w", err)
+	}
+	return hashed, nil
+}
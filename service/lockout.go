@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"peak-auth/utils"
+	"time"
+)
+
+// sessionPolicy devuelve la SESSION_POLICY de la app con los valores por defecto aplicados.
+func (s *userService) sessionPolicy(appID uint, defaultExpirationMinutes int) utils.SessionPolicy {
+	policy := utils.SessionPolicy{
+		TokenExpirationMinutes: defaultExpirationMinutes,
+		MaxFailedLogins:        5,
+	}
+
+	rules, err := s.ruleService.FindRulesByAppID(appID)
+	if err != nil {
+		return policy
+	}
+	for _, r := range rules {
+		if r.Code != "SESSION_POLICY" {
+			continue
+		}
+		sess, err := utils.ParseSessionPolicy(r.Value)
+		if err != nil {
+			continue
+		}
+		if sess.TokenExpirationMinutes > 0 {
+			policy.TokenExpirationMinutes = sess.TokenExpirationMinutes
+		}
+		if sess.MaxFailedLogins > 0 {
+			policy.MaxFailedLogins = sess.MaxFailedLogins
+		}
+		policy.LockoutMinutes = sess.LockoutMinutes
+		policy.MaxLockoutMinutes = sess.MaxLockoutMinutes
+		policy.ChallengeAfterFailures = sess.ChallengeAfterFailures
+		policy.ChallengeAfterIPFailures = sess.ChallengeAfterIPFailures
+		policy.ChallengeDifficulty = sess.ChallengeDifficulty
+		policy.ChallengeType = sess.ChallengeType
+	}
+	return policy
+}
+
+// checkLockout devuelve error si el usuario tiene un bloqueo vigente en la app.
+func (s *userService) checkLockout(userID, appID uint) error {
+	lockout, err := s.lockoutRepo.FindByUserAndApp(userID, appID)
+	if err != nil {
+		return fmt.Errorf("error al verificar el estado de la cuenta")
+	}
+	if lockout.LockedUntil != nil && time.Now().Before(*lockout.LockedUntil) {
+		remaining := int(math.Ceil(time.Until(*lockout.LockedUntil).Minutes()))
+		return fmt.Errorf("cuenta bloqueada temporalmente por exceso de intentos fallidos, reintentá en %d minuto(s)", remaining)
+	}
+	return nil
+}
+
+// registerFailedLogin suma un intento fallido en la app y, al llegar al máximo de la
+// SESSION_POLICY, bloquea la cuenta con backoff exponencial según los bloqueos previos.
+func (s *userService) registerFailedLogin(userID, appID uint, policy utils.SessionPolicy) {
+	now := time.Now()
+	// Si pasó más que el tope del backoff desde el último fallo, empezamos de cero
+	lockout, err := s.lockoutRepo.RegisterFailure(userID, appID, now, now.Add(-policy.LockoutDuration(math.MaxUint32)))
+	if err != nil {
+		log.Printf("bloqueos: no se pudo registrar el fallo del usuario %d en la app %d: %v", userID, appID, err)
+		return
+	}
+
+	if lockout.FailedLogins >= uint(policy.MaxFailedLogins) {
+		until := now.Add(policy.LockoutDuration(lockout.LockCount + 1))
+		if err := s.lockoutRepo.Lock(lockout.ID, uint(policy.MaxFailedLogins), until); err != nil {
+			log.Printf("bloqueos: no se pudo bloquear al usuario %d en la app %d: %v", userID, appID, err)
+		}
+	}
+}
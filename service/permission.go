@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"peak-auth/model"
+	"peak-auth/repository"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type PermissionService interface {
+	GetCatalog(publicAppID string) (response.PermissionCatalog, error)
+	CreatePermission(publicAppID string, req request.PermissionRequest) (model.Permission, error)
+	DeletePermission(publicAppID string, permissionID uint) error
+	SetRolePermissions(publicAppID, roleName string, codes []string) error
+}
+
+type permissionService struct {
+	permissionRepo repository.PermissionRepository
+	appRepo        repository.ApplicationRepository
+	roleRepo       repository.RoleRepository
+}
+
+func NewPermissionService(permissionRepo repository.PermissionRepository, appRepo repository.ApplicationRepository, roleRepo repository.RoleRepository) PermissionService {
+	return &permissionService{permissionRepo: permissionRepo, appRepo: appRepo, roleRepo: roleRepo}
+}
+
+// GetCatalog devuelve los permisos de la app y qué roles tienen cada uno (ROOT queda fuera:
+// es un rol de la consola).
+func (s *permissionService) GetCatalog(publicAppID string) (response.PermissionCatalog, error) {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.PermissionCatalog{}, fmt.Errorf("aplicación no encontrada")
+	}
+
+	permissions, err := s.permissionRepo.FindByApp(app.ID)
+	if err != nil {
+		return response.PermissionCatalog{}, fmt.Errorf("error al cargar los permisos: %w", err)
+	}
+	grants, err := s.permissionRepo.FindGrantsByApp(app.ID)
+	if err != nil {
+		return response.PermissionCatalog{}, fmt.Errorf("error al cargar los permisos de los roles: %w", err)
+	}
+	roles, err := s.roleRepo.FindForApp(app.ID)
+	if err != nil {
+		return response.PermissionCatalog{}, fmt.Errorf("error al cargar los roles: %w", err)
+	}
+
+	codes := make(map[uint]string, len(permissions))
+	for _, p := range permissions {
+		codes[p.ID] = p.Code
+	}
+	granted := map[uint]map[string]bool{}
+	for _, g := range grants {
+		if granted[g.RoleID] == nil {
+			granted[g.RoleID] = map[string]bool{}
+		}
+		granted[g.RoleID][codes[g.PermissionID]] = true
+	}
+
+	catalog := response.PermissionCatalog{Permissions: permissions}
+	for _, role := range roles {
+		if role.Name == "ROOT" {
+			continue
+		}
+		catalog.Roles = append(catalog.Roles, response.RolePermissionsRow{RoleID: role.ID, Name: role.Name, Granted: granted[role.ID]})
+	}
+	return catalog, nil
+}
+
+// CreatePermission agrega un permiso al catálogo de la app.
+func (s *permissionService) CreatePermission(publicAppID string, req request.PermissionRequest) (model.Permission, error) {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return model.Permission{}, fmt.Errorf("aplicación no encontrada")
+	}
+
+	code := utils.NormalizePermissionCode(req.Code)
+	if err := utils.ValidatePermissionCode(code); err != nil {
+		return model.Permission{}, err
+	}
+	if _, err := s.permissionRepo.FindByCode(app.ID, code); err == nil {
+		return model.Permission{}, fmt.Errorf("el permiso %s ya existe en esta aplicación", code)
+	}
+
+	permission := model.Permission{ApplicationID: app.ID, Code: code, Description: strings.TrimSpace(req.Description)}
+	if err := s.permissionRepo.Create(&permission); err != nil {
+		return model.Permission{}, fmt.Errorf("error al crear el permiso: %w", err)
+	}
+	return permission, nil
+}
+
+// DeletePermission quita el permiso del catálogo y de los roles que lo tenían.
+func (s *permissionService) DeletePermission(publicAppID string, permissionID uint) error {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return fmt.Errorf("aplicación no encontrada")
+	}
+
+	permissions, err := s.permissionRepo.FindByApp(app.ID)
+	if err != nil {
+		return fmt.Errorf("error al cargar los permisos: %w", err)
+	}
+	for _, p := range permissions {
+		if p.ID == permissionID {
+			return s.permissionRepo.Delete(p)
+		}
+	}
+	return fmt.Errorf("permiso no encontrado")
+}
+
+// SetRolePermissions reemplaza los permisos del rol en la app por los códigos indicados.
+func (s *permissionService) SetRolePermissions(publicAppID, roleName string, codes []string) error {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return fmt.Errorf("aplicación no encontrada")
+	}
+
+	role, err := s.roleRepo.FindByNameForApp(app.ID, roleName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("el rol no existe en esta aplicación")
+	}
+	if err != nil {
+		return err
+	}
+	if role.Name == "ROOT" {
+		return fmt.Errorf("el rol ROOT no admite permisos por aplicación")
+	}
+
+	ids := make([]uint, 0, len(codes))
+	seen := map[string]bool{}
+	for _, raw := range codes {
+		code := utils.NormalizePermissionCode(raw)
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		permission, err := s.permissionRepo.FindByCode(app.ID, code)
+		if err != nil {
+			return fmt.Errorf("el permiso %s no existe en esta aplicación", code)
+		}
+		ids = append(ids, permission.ID)
+	}
+
+	if err := s.permissionRepo.ReplaceRoleGrants(app.ID, role.ID, ids); err != nil {
+		return fmt.Errorf("error al guardar los permisos del rol: %w", err)
+	}
+	return nil
+}
+
+// tokenPermissions devuelve los permisos de los roles del usuario en la app para el JWT.
+// Ante un error el token se emite sin permisos (el cliente lo trata como "sin acceso").
+func (s *userService) tokenPermissions(appID uint, roles []model.Role) []string {
+	roleIDs := make([]uint, len(roles))
+	for i, r := range roles {
+		roleIDs[i] = r.ID
+	}
+	codes, err := s.permissionRepo.FindCodesByRoles(appID, roleIDs)
+	if err != nil || len(codes) == 0 {
+		return nil
+	}
+	return codes
+}
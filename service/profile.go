@@ -0,0 +1,167 @@
+package service
+
+import (
+	"fmt"
+	"peak-auth/model"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strings"
+	"time"
+)
+
+const birthDateLayout = "2006-01-02"
+
+// GetMe devuelve el usuario autenticado, su perfil y sus roles en la app del token.
+func (s *userService) GetMe(userID uint, publicAppID string) (response.MeResponse, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return response.MeResponse{}, fmt.Errorf("usuario no encontrado")
+	}
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.MeResponse{}, fmt.Errorf("aplicación no encontrada")
+	}
+	policy, err := s.ruleService.FindProfilePolicy(app.ID)
+	if err != nil {
+		return response.MeResponse{}, fmt.Errorf("error al leer PROFILE_POLICY: %w", err)
+	}
+	schema, attributes, err := s.loadAttributes(user.ID, app.ID)
+	if err != nil {
+		return response.MeResponse{}, err
+	}
+	return s.buildMe(user, app, policy, schema, attributes), nil
+}
+
+// UpdateMe aplica un PATCH sobre el perfil del usuario autenticado respetando la PROFILE_POLICY
+// de la app del token: sólo se aceptan los campos editables y el resultado debe ser válido.
+// Los atributos personalizados se validan contra la ATTRIBUTE_SCHEMA (sólo los editables por el usuario).
+func (s *userService) UpdateMe(userID uint, publicAppID string, req request.UpdateProfileRequest) (response.MeResponse, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return response.MeResponse{}, fmt.Errorf("usuario no encontrado")
+	}
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.MeResponse{}, fmt.Errorf("aplicación no encontrada")
+	}
+	policy, err := s.ruleService.FindProfilePolicy(app.ID)
+	if err != nil {
+		return response.MeResponse{}, fmt.Errorf("error al leer PROFILE_POLICY: %w", err)
+	}
+
+	profile := user.Profile
+	profile.UserID = user.ID
+
+	changes := map[string]bool{
+		utils.ProfileFieldFirstName: req.FirstName != nil,
+		utils.ProfileFieldLastName:  req.LastName != nil,
+		utils.ProfileFieldBirthDate: req.BirthDate != nil,
+		utils.ProfileFieldAvatarURL: req.AvatarURL != nil,
+	}
+	for _, field := range utils.ProfileFields {
+		if changes[field] && !policy.IsEditable(field) {
+			return response.MeResponse{}, fmt.Errorf("el campo %s no es editable en esta aplicación", field)
+		}
+	}
+
+	if req.FirstName != nil {
+		profile.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		profile.LastName = strings.TrimSpace(*req.LastName)
+	}
+	if req.BirthDate != nil {
+		profile.BirthDate = time.Time{}
+		if *req.BirthDate != "" {
+			birthDate, err := time.Parse(birthDateLayout, *req.BirthDate)
+			if err != nil {
+				return response.MeResponse{}, fmt.Errorf("birth_date debe tener el formato AAAA-MM-DD")
+			}
+			profile.BirthDate = birthDate
+		}
+	}
+	if req.AvatarURL != nil {
+		profile.AvatarURL = strings.TrimSpace(*req.AvatarURL)
+	}
+
+	if err := policy.ValidateProfile(utils.ProfileValues{
+		FirstName: profile.FirstName,
+		LastName:  profile.LastName,
+		BirthDate: profile.BirthDate,
+		AvatarURL: profile.AvatarURL,
+	}); err != nil {
+		return response.MeResponse{}, err
+	}
+
+	// El username es de la cuenta (sirve para el login en todas las apps), no del perfil
+	var username string
+	if req.Username != nil && (user.Username == nil || utils.NormalizeUsername(*req.Username) != *user.Username) {
+		if username, err = checkUsername(s.userRepo, *req.Username); err != nil {
+			return response.MeResponse{}, err
+		}
+	}
+
+	schema, attributes, err := s.loadAttributes(user.ID, app.ID)
+	if err != nil {
+		return response.MeResponse{}, err
+	}
+	if len(req.Attributes) > 0 {
+		attributes, err = schema.ApplyChanges(attributes, req.Attributes, false)
+		if err != nil {
+			return response.MeResponse{}, err
+		}
+	}
+
+	if username != "" {
+		// El índice único cubre la carrera con otro usuario que elija el mismo username
+		if err := s.userRepo.UpdateColumn("username", username, user.ID); err != nil {
+			return response.MeResponse{}, fmt.Errorf("error al actualizar el nombre de usuario: %w", err)
+		}
+		user.Username = &username
+	}
+	if err := s.userRepo.SaveProfile(&profile); err != nil {
+		return response.MeResponse{}, fmt.Errorf("error al actualizar el perfil: %w", err)
+	}
+	if len(req.Attributes) > 0 {
+		if err := s.attributeRepo.Save(user.ID, app.ID, attributes); err != nil {
+			return response.MeResponse{}, fmt.Errorf("error al guardar los atributos: %w", err)
+		}
+	}
+
+	user.Profile = profile
+	return s.buildMe(user, app, policy, schema, attributes), nil
+}
+
+func (s *userService) buildMe(user model.User, app model.Application, policy utils.ProfilePolicy, schema utils.AttributeSchema, attributes map[string]interface{}) response.MeResponse {
+	roleModels, _ := s.uarRepo.FindRolesByUserAndApp(user.ID, app.ID)
+	roles := make([]string, len(roleModels))
+	for i, r := range roleModels {
+		roles[i] = r.Name
+	}
+
+	var birthDate *string
+	if !user.Profile.BirthDate.IsZero() {
+		formatted := user.Profile.BirthDate.Format(birthDateLayout)
+		birthDate = &formatted
+	}
+
+	return response.MeResponse{
+		ID:         user.ID,
+		Email:      user.Email,
+		Username:   user.Username,
+		IsVerified: user.IsVerified,
+		LastLogin:  user.LastLogin,
+		Profile: response.ProfileResponse{
+			FirstName: user.Profile.FirstName,
+			LastName:  user.Profile.LastName,
+			BirthDate: birthDate,
+			AvatarURL: user.Profile.AvatarURL,
+		},
+		AppID:          app.AppID,
+		Roles:          roles,
+		EditableFields: policy.EditableFields(),
+		Attributes:     schema.Visible(attributes),
+		EditableAttrs:  schema.UserEditableKeys(),
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"peak-auth/model"
 	"peak-auth/repository"
+	"peak-auth/response"
+	"peak-auth/utils"
 	"strings"
 )
 
 type RoleService interface {
 	FindAll() ([]model.Role, error)
+	FindForApp(appID uint) ([]model.Role, error)
 	CreateRole(name string) error
+	CreateAppRole(appID uint, name string) error
 	DeleteRole(name string) error
+	DeleteAppRole(appID uint, name string) error
+	HierarchyForApp(appID uint) (response.RoleHierarchy, error)
+	SetAppRoleParents(appID uint, name string, parents []string) error
 }
 
 type roleService struct {
@@ -26,18 +33,45 @@ func (s *roleService) FindAll() ([]model.Role, error) {
 	return s.repo.FindAll()
 }
 
+// FindForApp devuelve los roles que se pueden asignar en la app: globales y propios.
+func (s *roleService) FindForApp(appID uint) ([]model.Role, error) {
+	return s.repo.FindForApp(appID)
+}
+
+// CreateRole crea un rol global, disponible en todas las aplicaciones.
 func (s *roleService) CreateRole(name string) error {
-	roleName := strings.ToUpper(name)
+	roleName := strings.ToUpper(strings.TrimSpace(name))
 
 	_, err := s.repo.FindByRoleName(roleName)
 	if err == nil {
 		return errors.New("el rol ya existe")
 	}
+	if exists, err := s.repo.ExistsInAnyApp(roleName); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("ya hay aplicaciones con un rol propio \"%s\"", roleName)
+	}
 
 	role := model.Role{Name: roleName}
 	return s.repo.Create(&role)
 }
 
+// CreateAppRole crea un rol propio de la aplicación. No puede repetir el nombre de un rol global.
+func (s *roleService) CreateAppRole(appID uint, name string) error {
+	roleName := strings.ToUpper(strings.TrimSpace(name))
+
+	existing, err := s.repo.FindByNameForApp(appID, roleName)
+	if err == nil {
+		if existing.IsGlobal() {
+			return fmt.Errorf("\"%s\" es un rol global y ya está disponible en la aplicación", roleName)
+		}
+		return errors.New("el rol ya existe en esta aplicación")
+	}
+
+	role := model.Role{Name: roleName, ApplicationID: &appID}
+	return s.repo.Create(&role)
+}
+
 func (s *roleService) DeleteRole(name string) error {
 	roleName := strings.ToUpper(name)
 
@@ -45,20 +79,147 @@ func (s *roleService) DeleteRole(name string) error {
 	if err != nil {
 		return errors.New("el rol no existe")
 	}
+	return s.deleteRole(role)
+}
+
+// DeleteAppRole elimina un rol propio de la aplicación. Los roles globales se eliminan con DeleteRole.
+func (s *roleService) DeleteAppRole(appID uint, name string) error {
+	roleName := strings.ToUpper(name)
 
+	role, err := s.repo.FindByNameForApp(appID, roleName)
+	if err != nil {
+		return errors.New("el rol no existe")
+	}
+	if role.IsGlobal() {
+		return fmt.Errorf("el rol \"%s\" es global: no pertenece a esta aplicación", roleName)
+	}
+	return s.deleteRole(role)
+}
+
+func (s *roleService) deleteRole(role model.Role) error {
 	// Proteger roles del sistema
 	if role.IsDefault {
-		return fmt.Errorf("el rol \"%s\" es un rol protegido del sistema y no puede eliminarse", roleName)
+		return fmt.Errorf("el rol \"%s\" es un rol protegido del sistema y no puede eliminarse", role.Name)
 	}
 
-	// Verificar que no haya usuarios asignados
+	// Verificar que no haya usuarios asignados (un rol propio sólo puede asignarse en su app)
 	count, err := s.repo.CountUsersWithRole(role.ID)
 	if err != nil {
 		return err
 	}
 	if count > 0 {
-		return fmt.Errorf("no se puede eliminar: %d usuario(s) tienen asignado el rol \"%s\"", count, roleName)
+		return fmt.Errorf("no se puede eliminar: %d usuario(s) tienen asignado el rol \"%s\"", count, role.Name)
 	}
 
 	return s.repo.Delete(role.ID)
 }
+
+// HierarchyForApp devuelve la herencia entre los roles válidos en la app (globales y propios).
+func (s *roleService) HierarchyForApp(appID uint) (response.RoleHierarchy, error) {
+	inheritances, err := s.repo.FindInheritances()
+	if err != nil {
+		return response.RoleHierarchy{}, fmt.Errorf("error al cargar la herencia de roles: %w", err)
+	}
+
+	hierarchy := response.RoleHierarchy{Parents: map[string][]string{}}
+	for _, ri := range inheritances {
+		if !ri.Role.IsGlobal() && *ri.Role.ApplicationID != appID {
+			continue
+		}
+		hierarchy.Parents[ri.Role.Name] = append(hierarchy.Parents[ri.Role.Name], ri.Parent.Name)
+	}
+	return hierarchy, nil
+}
+
+// SetAppRoleParents reemplaza los roles que hereda un rol propio de la app. Los padres pueden ser
+// globales o de la misma app; se rechaza cualquier cambio que forme un ciclo.
+func (s *roleService) SetAppRoleParents(appID uint, name string, parents []string) error {
+	role, err := s.repo.FindByNameForApp(appID, strings.ToUpper(name))
+	if err != nil {
+		return errors.New("el rol no existe")
+	}
+	if role.IsGlobal() {
+		return fmt.Errorf("el rol \"%s\" es global: sólo los roles propios de la aplicación pueden heredar", role.Name)
+	}
+
+	parentIDs := make([]uint, 0, len(parents))
+	seen := map[uint]bool{}
+	for _, parentName := range parents {
+		parent, err := s.repo.FindByNameForApp(appID, strings.ToUpper(strings.TrimSpace(parentName)))
+		if err != nil {
+			return fmt.Errorf("el rol \"%s\" no existe en esta aplicación", strings.ToUpper(parentName))
+		}
+		if parent.Name == "ROOT" {
+			return errors.New("ningún rol puede heredar ROOT")
+		}
+		// Heredarlo sería una forma de otorgarlo sin ser ROOT
+		if parent.Name == utils.RoleImpersonator {
+			return fmt.Errorf("ningún rol puede heredar %s", utils.RoleImpersonator)
+		}
+		if parent.ID == role.ID {
+			return errors.New("un rol no puede heredarse a sí mismo")
+		}
+		if !seen[parent.ID] {
+			seen[parent.ID] = true
+			parentIDs = append(parentIDs, parent.ID)
+		}
+	}
+
+	inheritances, err := s.repo.FindInheritances()
+	if err != nil {
+		return fmt.Errorf("error al cargar la herencia de roles: %w", err)
+	}
+	graph := map[uint][]uint{}
+	names := map[uint]string{}
+	for _, ri := range inheritances {
+		names[ri.RoleID], names[ri.ParentID] = ri.Role.Name, ri.Parent.Name
+		if ri.RoleID != role.ID {
+			graph[ri.RoleID] = append(graph[ri.RoleID], ri.ParentID)
+		}
+	}
+	graph[role.ID] = parentIDs
+	if cycle := findRoleCycle(graph, role.ID); cycle != nil {
+		path := make([]string, len(cycle))
+		for i, id := range cycle {
+			path[i] = names[id]
+			if id == role.ID {
+				path[i] = role.Name
+			}
+		}
+		return fmt.Errorf("la herencia forma un ciclo: %s", strings.Join(path, " → "))
+	}
+
+	if err := s.repo.ReplaceParents(role.ID, parentIDs); err != nil {
+		return fmt.Errorf("error al guardar la herencia del rol: %w", err)
+	}
+	return nil
+}
+
+// findRoleCycle busca un camino de herencia que vuelva a `start` y lo devuelve (start … start),
+// o nil si no hay ciclo.
+func findRoleCycle(graph map[uint][]uint, start uint) []uint {
+	visited := map[uint]bool{}
+	var path []uint
+	var visit func(id uint) bool
+	visit = func(id uint) bool {
+		path = append(path, id)
+		for _, parent := range graph[id] {
+			if parent == start {
+				path = append(path, start)
+				return true
+			}
+			if !visited[parent] {
+				visited[parent] = true
+				if visit(parent) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(start) {
+		return path
+	}
+	return nil
+}
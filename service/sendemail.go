@@ -2,8 +2,10 @@ package service
 
 import (
 	"fmt"
+	"html"
 	"log"
 	"os"
+	"time"
 
 	"github.com/resend/resend-go/v2"
 )
@@ -66,8 +68,8 @@ func NewEmailService() *EmailService {
 	return &EmailService{Provider: provider}
 }
 
-func (s *EmailService) SendVerificationEmail(toEmail string, token string) error {
-	// Link de ejemplo (esto debería apuntar a tu UI front de activación)
+// baseURL arma la URL pública del servidor a partir de HOST y PORT.
+func baseURL() string {
 	host := os.Getenv("HOST")
 	if host == "" {
 		host = "localhost"
@@ -76,8 +78,12 @@ func (s *EmailService) SendVerificationEmail(toEmail string, token string) error
 	if port == "" {
 		port = "9009"
 	}
+	return fmt.Sprintf("http://%s:%s", host, port)
+}
 
-	link := fmt.Sprintf("http://%s:%s/api/v1/reset-password?token=%s", host, port, token)
+func (s *EmailService) SendVerificationEmail(toEmail string, token string) error {
+	// Link de ejemplo (esto debería apuntar a tu UI front de activación)
+	link := fmt.Sprintf("%s/api/v1/reset-password?token=%s", baseURL(), token)
 	subject := "Verifica tu cuenta en Peak Auth"
 	html := fmt.Sprintf(`
 		<h1>¡Bienvenido!</h1>
@@ -89,3 +95,147 @@ func (s *EmailService) SendVerificationEmail(toEmail string, token string) error
 
 	return s.Provider.Send(subject, toEmail, html)
 }
+
+// SecurityEvent identifica un cambio sensible de la cuenta que se notifica al usuario.
+type SecurityEvent string
+
+const (
+	SecurityEventPasswordChanged SecurityEvent = "password_changed"
+	SecurityEventEmailChanged    SecurityEvent = "email_changed"
+	SecurityEventMFAChanged      SecurityEvent = "mfa_changed"
+)
+
+var securityEventTexts = map[SecurityEvent][2]string{
+	SecurityEventPasswordChanged: {"Tu contraseña de Peak Auth fue modificada", "La contraseña de tu cuenta acaba de ser modificada."},
+	SecurityEventEmailChanged:    {"El email de tu cuenta de Peak Auth fue modificado", "El email de acceso de tu cuenta acaba de ser modificado."},
+	SecurityEventMFAChanged:      {"La verificación en dos pasos de tu cuenta fue modificada", "La configuración de verificación en dos pasos (MFA) de tu cuenta acaba de ser modificada."},
+}
+
+// SendSecurityNotificationEmail avisa al usuario de un cambio sensible en su cuenta.
+func (s *EmailService) SendSecurityNotificationEmail(toEmail string, event SecurityEvent) error {
+	texts, ok := securityEventTexts[event]
+	if !ok {
+		return fmt.Errorf("evento de seguridad desconocido: %s", event)
+	}
+	html := fmt.Sprintf(`
+		<h1>Aviso de seguridad</h1>
+		<p>%s</p>
+		<p>Fecha: %s</p>
+		<p>Si no fuiste vos, restablecé tu contraseña de inmediato y contactá al administrador de la aplicación.</p>
+	`, texts[1], time.Now().Format("02/01/2006 15:04 MST"))
+
+	return s.Provider.Send(texts[0], toEmail, html)
+}
+
+// SendNewDeviceLoginEmail avisa de un inicio de sesión desde un dispositivo no reconocido,
+// con un link para cerrar esa sesión.
+func (s *EmailService) SendNewDeviceLoginEmail(toEmail, appName, userAgent, ip string, at time.Time, revokeToken string) error {
+	link := fmt.Sprintf("%s/api/v1/sessions/revoke?token=%s", baseURL(), revokeToken)
+	subject := "Nuevo inicio de sesión en tu cuenta"
+	body := fmt.Sprintf(`
+		<h1>Nuevo inicio de sesión</h1>
+		<p>Detectamos un inicio de sesión en <strong>%s</strong> desde un dispositivo que no reconocemos.</p>
+		<ul>
+			<li>Dispositivo: %s</li>
+			<li>IP: %s</li>
+			<li>Fecha: %s</li>
+		</ul>
+		<p>Si fuiste vos, podés ignorar este mensaje. Si no, cerrá esa sesión y cambiá tu contraseña:</p>
+		<a href="%s" style="background: #dc2626; color: white; padding: 10px 20px; border-radius: 5px; text-decoration: none;">Cerrar esa sesión</a>
+		<p>Si el botón no funciona, copia y pega esto: %s</p>
+	`, html.EscapeString(appName), html.EscapeString(userAgent), html.EscapeString(ip), at.Format("02/01/2006 15:04 MST"), link, link)
+
+	return s.Provider.Send(subject, toEmail, body)
+}
+
+// SendEmailChangeConfirmation envía a la nueva dirección el link para confirmar el cambio de email.
+func (s *EmailService) SendEmailChangeConfirmation(toEmail, token string) error {
+	link := fmt.Sprintf("%s/api/v1/me/email/confirm?token=%s", baseURL(), token)
+	subject := "Confirmá tu nuevo email en Peak Auth"
+	html := fmt.Sprintf(`
+		<h1>Confirmá tu nuevo email</h1>
+		<p>Solicitaste usar esta dirección para iniciar sesión en tu cuenta de Peak Auth.</p>
+		<p>Para confirmar el cambio, haz clic en el siguiente enlace (vence en 24 horas):</p>
+		<a href="%s" style="background: #4f46e5; color: white; padding: 10px 20px; border-radius: 5px; text-decoration: none;">Confirmar email</a>
+		<p>Si el botón no funciona, copia y pega esto: %s</p>
+		<p>Si no lo solicitaste, ignorá este mensaje.</p>
+	`, link, link)
+
+	return s.Provider.Send(subject, toEmail, html)
+}
+
+// SendEmailChangeNotice avisa a la dirección actual del pedido de cambio, con un link para cancelarlo.
+func (s *EmailService) SendEmailChangeNotice(toEmail, newEmail, cancelToken string) error {
+	link := fmt.Sprintf("%s/api/v1/me/email/cancel?token=%s", baseURL(), cancelToken)
+	subject := "Se solicitó cambiar el email de tu cuenta"
+	body := fmt.Sprintf(`
+		<h1>Cambio de email solicitado</h1>
+		<p>Se pidió reemplazar el email de tu cuenta por <strong>%s</strong>.</p>
+		<p>El cambio sólo se aplica cuando se confirme desde la nueva dirección.</p>
+		<p>Si no fuiste vos, cancelalo y cambiá tu contraseña:</p>
+		<a href="%s" style="background: #dc2626; color: white; padding: 10px 20px; border-radius: 5px; text-decoration: none;">Cancelar el cambio</a>
+		<p>Si el botón no funciona, copia y pega esto: %s</p>
+	`, html.EscapeString(newEmail), link, link)
+
+	return s.Provider.Send(subject, toEmail, body)
+}
+
+// SendAccountDeletionScheduledEmail confirma la baja programada e incluye un link para cancelarla.
+func (s *EmailService) SendAccountDeletionScheduledEmail(toEmail string, scheduledFor time.Time, cancelToken string) error {
+	link := fmt.Sprintf("%s/api/v1/me/deletion/cancel?token=%s", baseURL(), cancelToken)
+	subject := "Programaste la eliminación de tu cuenta"
+	html := fmt.Sprintf(`
+		<h1>Eliminación de cuenta programada</h1>
+		<p>Tu cuenta y todos sus datos se eliminarán el <strong>%s</strong>.</p>
+		<p>Hasta esa fecha podés seguir usándola y cancelar la baja:</p>
+		<a href="%s" style="background: #4f46e5; color: white; padding: 10px 20px; border-radius: 5px; text-decoration: none;">Cancelar la eliminación</a>
+		<p>Si el botón no funciona, copia y pega esto: %s</p>
+	`, scheduledFor.Format("02/01/2006 15:04 MST"), link, link)
+
+	return s.Provider.Send(subject, toEmail, html)
+}
+
+// SendInvitationEmail envía el link para aceptar la invitación a una aplicación.
+func (s *EmailService) SendInvitationEmail(toEmail, appName string, expiresAt time.Time, token string) error {
+	link := fmt.Sprintf("%s/api/v1/invitations/accept?token=%s", baseURL(), token)
+	subject := fmt.Sprintf("Te invitaron a %s", appName)
+	body := fmt.Sprintf(`
+		<h1>¡Te invitaron a %s!</h1>
+		<p>Para aceptar la invitación, completá tu nombre y elegí una contraseña:</p>
+		<a href="%s" style="background: #4f46e5; color: white; padding: 10px 20px; border-radius: 5px; text-decoration: none;">Aceptar invitación</a>
+		<p>El link vence el %s.</p>
+		<p>Si el botón no funciona, copia y pega esto: %s</p>
+	`, html.EscapeString(appName), link, expiresAt.Format("02/01/2006 15:04 MST"), link)
+
+	return s.Provider.Send(subject, toEmail, body)
+}
+
+// SendImpersonationEmail avisa al usuario de que un administrador inició una sesión en su nombre.
+func (s *EmailService) SendImpersonationEmail(toEmail, appName, reason string, expiresAt time.Time) error {
+	subject := "Un administrador accedió a tu cuenta"
+	body := fmt.Sprintf(`
+		<h1>Acceso de soporte a tu cuenta</h1>
+		<p>Un administrador inició una sesión en tu nombre en <strong>%s</strong> para revisar tu cuenta.</p>
+		<ul>
+			<li>Motivo: %s</li>
+			<li>Fecha: %s</li>
+			<li>La sesión vence el %s</li>
+		</ul>
+		<p>Durante esa sesión no se puede cambiar tu contraseña, tu email ni eliminar tu cuenta.</p>
+		<p>Si no reconocés este acceso, contactá al administrador de la aplicación.</p>
+	`, html.EscapeString(appName), html.EscapeString(reason), time.Now().Format("02/01/2006 15:04 MST"), expiresAt.Format("02/01/2006 15:04 MST"))
+
+	return s.Provider.Send(subject, toEmail, body)
+}
+
+// SendRoleExpiredEmail avisa al usuario de que venció un rol temporal que tenía en una aplicación.
+func (s *EmailService) SendRoleExpiredEmail(toEmail, appName, roleName string, expiredAt time.Time) error {
+	subject := "Venció un acceso temporal de tu cuenta"
+	body := fmt.Sprintf(`
+		<h1>Acceso temporal vencido</h1>
+		<p>El rol <strong>%s</strong> que tenías en <strong>%s</strong> venció el %s y ya no está asignado a tu cuenta.</p>
+		<p>Si todavía necesitás ese acceso, pedíselo al administrador de la aplicación.</p>
+	`, html.EscapeString(roleName), html.EscapeString(appName), expiredAt.Format("02/01/2006 15:04 MST"))
+
+	return s.Provider.Send(subject, toEmail, body)
+}
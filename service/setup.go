@@ -66,6 +66,7 @@ func (s *setupService) CreateRootUser(email, password, token string) (model.User
 		rootRole = model.Role{Name: "ROOT", IsDefault: true}
 		adminRole := model.Role{Name: "ADMIN", IsDefault: true}
 		userRole := model.Role{Name: "USER", IsDefault: true}
+		impersonatorRole := model.Role{Name: utils.RoleImpersonator, IsDefault: true}
 
 		if err = tx.Roles().Create(&rootRole); err != nil {
 			return err
@@ -76,7 +77,10 @@ func (s *setupService) CreateRootUser(email, password, token string) (model.User
 		if err = tx.Roles().Create(&userRole); err != nil {
 			return err
 		}
-		user = model.User{Email: email, Password: hashedPassword, IsVerified: true}
+		if err = tx.Roles().Create(&impersonatorRole); err != nil {
+			return err
+		}
+		user = model.User{Email: utils.NormalizeEmail(email), Password: hashedPassword, IsVerified: true}
 		if err := tx.Users().CreateWithProfile(&user, &profile); err != nil {
 			return err
 		}
@@ -28,7 +28,33 @@ type UserService interface {
 	FindUserByAppID(appID string) ([]response.UserAppRow, error)
 	FindUserByAppIDPaginated(appID string, page, limit int) ([]response.UserAppRow, int64, error)
 	Refresh(token string) (response.TokenResponse, error)
-	UnlockUser(userID uint) error
+	UnlockUser(userID, appID uint) error
+	ChangePassword(userID uint, publicAppID string, req request.ChangePasswordRequest) error
+	CheckPasswordStrength(publicAppID string, req request.PasswordStrengthRequest) (response.PasswordStrengthResponse, error)
+	IssueLoginChallenge(publicAppID, identifier string) (response.ChallengeResponse, error)
+	RevokeSession(token string) error
+	GetMe(userID uint, publicAppID string) (response.MeResponse, error)
+	UpdateMe(userID uint, publicAppID string, req request.UpdateProfileRequest) (response.MeResponse, error)
+	RequestEmailChange(userID uint, publicAppID string, req request.ChangeEmailRequest) error
+	ConfirmEmailChange(token string) error
+	CancelEmailChange(token string) error
+	ExportAccount(userID uint) (response.AccountExport, error)
+	ScheduleAccountDeletion(userID uint, req request.DeleteAccountRequest) (time.Time, error)
+	CancelAccountDeletion(userID uint) error
+	CancelAccountDeletionByToken(token string) error
+	PurgeDueAccounts() (int, error)
+	StartDeletionSweeper(interval time.Duration)
+	SearchUsers(filter request.UserDirectoryFilter) ([]response.UserDirectoryRow, int64, error)
+	GetUserDetail(userID uint) (response.UserDetail, error)
+	ResendVerification(userID uint) error
+	SendResetEmailByID(userID uint) error
+	RevokeAllSessions(userID uint) error
+	UnlockAll(userID uint) error
+	ResendVerificationByEmail(email string)
+	SetUserStatus(actorID, userID uint, req request.UserRequest) error
+	GetUserAttributes(userID uint, publicAppID string) (response.UserAttributesView, error)
+	UpdateUserAttributes(userID uint, publicAppID string, changes map[string]interface{}) (response.UserAttributesView, error)
+	StartImpersonation(actorID, userID uint, req request.ImpersonationRequest) (response.ImpersonationToken, error)
 }
 
 type userService struct {
@@ -42,77 +68,90 @@ type userService struct {
 	passwordResetRepo     repository.PasswordResetRepository
 	emailService          *EmailService
 	refreshTokenRepo      repository.RefreshTokenRepository
+	lockoutRepo           repository.UserLockoutRepository
+	loginAttemptRepo      repository.LoginAttemptRepository
+	challengeManager      *auth.ChallengeManager
+	captchaVerifier       auth.CaptchaVerifier
+	deviceRepo            repository.UserDeviceRepository
+	emailChangeRepo       repository.EmailChangeRepository
+	accountExportRepo     repository.AccountExportRepository
+	accountDeletionRepo   repository.AccountDeletionRepository
+	attributeRepo         repository.UserAttributeRepository
+	impersonationRepo     repository.ImpersonationRepository
+	permissionRepo        repository.PermissionRepository
 }
 
 // NewUserService crea una instancia de UserService con las dependencias necesarias.
-func NewUserService(userRepo repository.UserRepository, roleRepo repository.RoleRepository, uarRepo repository.UserApplicationRoleRepository, appRepo repository.ApplicationRepository, ruleService ApplicationRuleService, tokenManager *auth.JWTManager, emailVerificationRepo repository.EmailVerificationRepository, passwordResetRepo repository.PasswordResetRepository, emailService *EmailService, refreshTokenRepo repository.RefreshTokenRepository) UserService {
-	return &userService{userRepo: userRepo, roleRepo: roleRepo, uarRepo: uarRepo, appRepo: appRepo, ruleService: ruleService, tokenManager: tokenManager, emailVerificationRepo: emailVerificationRepo, passwordResetRepo: passwordResetRepo, emailService: emailService, refreshTokenRepo: refreshTokenRepo}
+func NewUserService(userRepo repository.UserRepository, roleRepo repository.RoleRepository, uarRepo repository.UserApplicationRoleRepository, appRepo repository.ApplicationRepository, ruleService ApplicationRuleService, tokenManager *auth.JWTManager, emailVerificationRepo repository.EmailVerificationRepository, passwordResetRepo repository.PasswordResetRepository, emailService *EmailService, refreshTokenRepo repository.RefreshTokenRepository, lockoutRepo repository.UserLockoutRepository, loginAttemptRepo repository.LoginAttemptRepository, challengeManager *auth.ChallengeManager, captchaVerifier auth.CaptchaVerifier, deviceRepo repository.UserDeviceRepository, emailChangeRepo repository.EmailChangeRepository, accountExportRepo repository.AccountExportRepository, accountDeletionRepo repository.AccountDeletionRepository, attributeRepo repository.UserAttributeRepository, impersonationRepo repository.ImpersonationRepository, permissionRepo repository.PermissionRepository) UserService {
+	return &userService{userRepo: userRepo, roleRepo: roleRepo, uarRepo: uarRepo, appRepo: appRepo, ruleService: ruleService, tokenManager: tokenManager, emailVerificationRepo: emailVerificationRepo, passwordResetRepo: passwordResetRepo, emailService: emailService, refreshTokenRepo: refreshTokenRepo, lockoutRepo: lockoutRepo, loginAttemptRepo: loginAttemptRepo, challengeManager: challengeManager, captchaVerifier: captchaVerifier, deviceRepo: deviceRepo, emailChangeRepo: emailChangeRepo, accountExportRepo: accountExportRepo, accountDeletionRepo: accountDeletionRepo, attributeRepo: attributeRepo, impersonationRepo: impersonationRepo, permissionRepo: permissionRepo}
 }
 
 // Login valida credenciales, comprueba estado del usuario y genera un token JWT.
 func (s *userService) Login(req request.LoginRequest, publicAppID string) (response.TokenResponse, error) {
-	// 1. Validar Usuario y Aplicación
-	user, err := s.userRepo.FindByEmail(req.Email)
-	if err != nil {
-		return response.TokenResponse{}, fmt.Errorf("credenciales inválidas")
-	}
-
+	// 1. Validar Aplicación y Usuario (por email o username según la LOGIN_POLICY)
 	app, err := s.appRepo.FindByAppID(publicAppID)
 	if err != nil {
 		return response.TokenResponse{}, fmt.Errorf("aplicación no autorizada")
 	}
 
-	// 2. Aplicar política de intentos fallidos (SESSION_POLICY)
-	maxFails := 5 // Default
-	rules, err := s.ruleService.FindRulesByAppID(app.ID)
-	if err == nil {
-		for _, r := range rules {
-			if r.Code == "SESSION_POLICY" {
-				sess, err := utils.ParseSessionPolicy(r.Value)
-				if err == nil && sess.MaxFailedLogins > 0 {
-					maxFails = sess.MaxFailedLogins
-				}
-			}
-		}
+	user, userErr := s.findLoginUser(app.ID, req.Identifier())
+
+	// 2. Desafío ante fallos sospechosos de la cuenta o de la IP (SESSION_POLICY)
+	sessionPolicy := s.sessionPolicy(app.ID, 24*60)
+	var userID *uint
+	if userErr == nil {
+		userID = &user.ID
+	}
+	if err := s.verifyLoginChallenge(req, app.AppID, app.ID, sessionPolicy); err != nil {
+		s.recordLoginAttempt(userID, app.ID, req, false, "challenge_required")
+		return response.TokenResponse{}, err
 	}
 
-	if user.FailedLogins >= uint(maxFails) {
-		return response.TokenResponse{}, fmt.Errorf("cuenta bloqueada por exceso de intentos fallidos")
+	if userErr != nil {
+		s.recordLoginAttempt(nil, app.ID, req, false, "unknown_user")
+		return response.TokenResponse{}, fmt.Errorf("credenciales inválidas")
+	}
+
+	// 3. Aplicar política de intentos fallidos (SESSION_POLICY), por usuario y aplicación
+	if err := s.checkLockout(user.ID, app.ID); err != nil {
+		s.recordLoginAttempt(userID, app.ID, req, false, "locked")
+		return response.TokenResponse{}, err
 	}
 
-	// 3. Validar Password
+	// 4. Validar Password
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		s.userRepo.UpdateColumn("failed_logins", user.FailedLogins+1, user.ID)
+		s.registerFailedLogin(user.ID, app.ID, sessionPolicy)
+		s.recordLoginAttempt(userID, app.ID, req, false, "bad_password")
 		return response.TokenResponse{}, fmt.Errorf("credenciales inválidas")
 	}
 
 	if !user.IsVerified {
+		s.recordLoginAttempt(userID, app.ID, req, false, "unverified")
 		return response.TokenResponse{}, fmt.Errorf("usuario no verificado")
 	}
 
 	if !user.IsActive {
+		s.recordLoginAttempt(userID, app.ID, req, false, "inactive")
 		return response.TokenResponse{}, fmt.Errorf("usuario está desactivado")
 	}
 
-	// Login exitoso: Resetear contador de fallos
-	s.userRepo.UpdateColumn("failed_logins", 0, user.ID)
+	// Login exitoso: Resetear contador de fallos y backoff en esta app
+	_ = s.lockoutRepo.Reset(user.ID, app.ID)
 
-	// 3. Validar reglas de autorización (AUTHZ_POLICY)
+	// 5. Validar reglas de autorización (AUTHZ_POLICY)
 	if err := s.ruleService.ValidateLogin(app.ID, user.ID); err != nil {
+		s.recordLoginAttempt(userID, app.ID, req, false, "forbidden")
 		return response.TokenResponse{}, err
 	}
 
-	// 4. Aplicar duración de sesión (SESSION_POLICY)
-	duration := time.Hour * 24
-	for _, r := range rules {
-		if r.Code == "SESSION_POLICY" {
-			sess, err := utils.ParseSessionPolicy(r.Value)
-			if err == nil && sess.TokenExpirationMinutes > 0 {
-				duration = time.Duration(sess.TokenExpirationMinutes) * time.Minute
-			}
-		}
+	// Sin registro del intento no se entrega el token (ver recordLoginAttempt)
+	if err := s.recordLoginAttempt(userID, app.ID, req, true, ""); err != nil {
+		return response.TokenResponse{}, err
 	}
 
+	// 4. Aplicar duración de sesión (SESSION_POLICY)
+	duration := time.Duration(sessionPolicy.TokenExpirationMinutes) * time.Minute
+
 	// 3.5 Obtener roles para el JWT
 	roleModels, _ := s.uarRepo.FindRolesByUserAndApp(user.ID, app.ID)
 	roles := make([]string, len(roleModels))
@@ -120,22 +159,28 @@ func (s *userService) Login(req request.LoginRequest, publicAppID string) (respo
 		roles[i] = r.Name
 	}
 
-	// 4. Generar Token JWT
-	token, err := s.tokenManager.GenerateToken(user.ID, user.Email, publicAppID, roles, duration)
+	// 4. Generar Token JWT (con los atributos in_token de la ATTRIBUTE_SCHEMA)
+	duration = s.tokenDuration(user.ID, app.ID, duration)
+	token, err := s.tokenManager.GenerateTokenWithExtras(user.ID, user.Email, publicAppID, roles, s.tokenExtras(user.ID, app.ID, roleModels), duration)
 	if err != nil {
 		return response.TokenResponse{}, err
 	}
 
-	// 5. Generar y Almacenar Refresh Token
+	// 5. Generar y Almacenar Refresh Token (asociado al dispositivo del login)
+	device := s.recognizeDevice(user.ID, req)
 	plainRT, _, err := utils.GenerateToken(64)
 	if err == nil {
 		rt := model.RefreshToken{
-			UserID:        user.ID,
-			ApplicationID: app.ID,
-			Token:         plainRT,
-			ExpiresAt:     time.Now().Add(7 * 24 * time.Hour), // 7 días
+			UserID:          user.ID,
+			ApplicationID:   app.ID,
+			Token:           plainRT,
+			ExpiresAt:       time.Now().Add(7 * 24 * time.Hour), // 7 días
+			UserDeviceID:    device.DeviceID,
+			RevokeTokenHash: device.RevokeHash,
+		}
+		if err := s.refreshTokenRepo.Create(&rt); err == nil && device.RevokeToken != "" {
+			go s.emailService.SendNewDeviceLoginEmail(user.Email, app.Name, truncate(req.UserAgent, 255), req.ClientIP, time.Now(), device.RevokeToken)
 		}
-		_ = s.refreshTokenRepo.Create(&rt)
 	}
 
 	s.userRepo.UpdateColumn("last_login", time.Now(), user.ID)
@@ -149,6 +194,7 @@ func (s *userService) Login(req request.LoginRequest, publicAppID string) (respo
 // Register crea un usuario respetando las reglas de la aplicación,
 // asigna un rol por defecto si corresponde y envía email de verificación.
 func (s *userService) Register(req request.RegisterRequest) (model.User, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
 
 	// Verificar app objetivo
 	app, err := s.appRepo.FindByAppID(req.AppID)
@@ -172,16 +218,22 @@ func (s *userService) Register(req request.RegisterRequest) (model.User, error)
 	}
 
 	// 2) Reglas por app (validateRegistration devuelve la política de registro)
-	registrationPolicy, err := s.ruleService.ValidateRegistration(app.ID, req)
+	registrationPolicy, err := s.ruleService.ValidateRegistration(app, req)
 	if err != nil {
 		return model.User{}, err
 	}
 
 	// 3) Crear usuario si no existe
 	if !userExists {
+		username, err := checkUsername(s.userRepo, req.Username)
+		if err != nil {
+			return model.User{}, err
+		}
+
 		nu, _ := req.ToUser()
+		nu.Username = &username
 		profile := model.Profile{FirstName: req.FirstName, LastName: req.LastName}
-		
+
 		// Si la política de la app dice que no requiere verificar, lo creamos ya verificado.
 		if !registrationPolicy.RequireEmailVerification {
 			nu.IsVerified = true
@@ -195,7 +247,7 @@ func (s *userService) Register(req request.RegisterRequest) (model.User, error)
 
 	// 4) Asignar rol por reglas
 	if registrationPolicy.DefaultRole != "" {
-		if role, err := s.roleRepo.FindByRoleName(registrationPolicy.DefaultRole); err == nil {
+		if role, err := s.roleRepo.FindByNameForApp(app.ID, registrationPolicy.DefaultRole); err == nil {
 			_ = s.uarRepo.AssignRole(user.ID, app.ID, role.ID)
 		}
 	}
@@ -206,9 +258,20 @@ func (s *userService) Register(req request.RegisterRequest) (model.User, error)
 	}
 
 	// 6) Envío de email de verificación...
+	if err := s.sendVerificationEmail(user); err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// sendVerificationEmail genera un token de verificación para el usuario y envía el email.
+// Una vez enviado, los tokens emitidos antes quedan invalidados: sólo el último link es válido.
+// Si el envío falla se descarta el token nuevo y los anteriores siguen sirviendo.
+func (s *userService) sendVerificationEmail(user model.User) error {
 	plainToken, tokenHash, err := utils.GenerateToken(32)
 	if err != nil {
-		return model.User{}, err
+		return err
 	}
 
 	verification := model.EmailVerification{
@@ -218,7 +281,7 @@ func (s *userService) Register(req request.RegisterRequest) (model.User, error)
 	}
 
 	if err := s.emailVerificationRepo.CreateEmailVerification(&verification); err != nil {
-		return model.User{}, err
+		return err
 	}
 
 	url := os.Getenv("VERIFY_URL")
@@ -229,14 +292,19 @@ func (s *userService) Register(req request.RegisterRequest) (model.User, error)
 	})
 
 	if err != nil {
-		return model.User{}, fmt.Errorf("error al renderizar email: %w", err)
+		_ = s.emailVerificationRepo.Delete(verification.ID)
+		return fmt.Errorf("error al renderizar email: %w", err)
 	}
 
 	if err := s.emailService.Provider.Send("Verificá tu email", user.Email, htmlBody); err != nil {
-		return model.User{}, fmt.Errorf("error enviando email: %v", err)
+		_ = s.emailVerificationRepo.Delete(verification.ID)
+		return fmt.Errorf("error enviando email: %v", err)
 	}
 
-	return user, nil
+	if err := s.emailVerificationRepo.ExpirePendingByUser(user.ID, verification.ID); err != nil {
+		return fmt.Errorf("error al invalidar verificaciones previas: %w", err)
+	}
+	return nil
 }
 
 // FindAll devuelve todos los usuarios con su perfil cargado.
@@ -261,7 +329,7 @@ func (s *userService) VerifyEmail(token string) error {
 
 // FindVerifiedUser retorna el usuario si existe y está verificado por email.
 func (s *userService) FindVerifiedUser(email string) (*model.User, error) {
-	user, err := s.userRepo.FindByEmail(email)
+	user, err := s.userRepo.FindByEmail(utils.NormalizeEmail(email))
 	if err != nil {
 		return nil, fmt.Errorf("usuario no encontrado")
 	}
@@ -319,11 +387,20 @@ func (s *userService) ResetPassword(token, newPassword string) error {
 	}
 
 	// 1. Aplicar reglas de la aplicación (PWD_POLICY)
+	var userInputs []string
+	user, userErr := s.userRepo.FindById(reset.UserID)
+	if userErr == nil {
+		userInputs = append(userInputs, user.Email, user.Profile.FirstName, user.Profile.LastName)
+	}
+	if app, err := s.appRepo.FindByID(reset.ApplicationID); err == nil {
+		userInputs = append(userInputs, app.Name)
+	}
+
 	rules, err := s.ruleService.FindRulesByAppID(reset.ApplicationID)
 	if err == nil {
 		for _, r := range rules {
 			if r.Code == "PWD_POLICY" {
-				if err := utils.ValidatePasswordPolicy(r.Value, newPassword); err != nil {
+				if err := utils.ValidatePasswordPolicy(r.Value, newPassword, userInputs...); err != nil {
 					return err
 				}
 			}
@@ -351,11 +428,16 @@ func (s *userService) ResetPassword(token, newPassword string) error {
 		return fmt.Errorf("error al verificar la cuenta: %w", err)
 	}
 
+	// Sólo es un cambio de contraseña si la cuenta ya estaba activada
+	if userErr == nil && user.IsVerified {
+		go s.emailService.SendSecurityNotificationEmail(user.Email, SecurityEventPasswordChanged)
+	}
+
 	return nil
 }
 
 func (s *userService) AdminLogin(email, password string) (string, int, error) {
-	user, err := s.userRepo.FindByEmail(email)
+	user, err := s.userRepo.FindByEmail(utils.NormalizeEmail(email))
 	if err != nil {
 		return "", 0, fmt.Errorf("credenciales de administrador inválidas")
 	}
@@ -366,35 +448,23 @@ func (s *userService) AdminLogin(email, password string) (string, int, error) {
 	}
 
 	// 1. Aplicar política de intentos fallidos (SESSION_POLICY de Peak Auth Raíz)
-	maxFails := 5 // Default
-	expireMinutes := 720 // Default 12h
-	rules, err := s.ruleService.FindRulesByAppID(peakApp.ID)
-	if err == nil {
-		for _, r := range rules {
-			if r.Code == "SESSION_POLICY" {
-				sess, err := utils.ParseSessionPolicy(r.Value)
-				if err == nil {
-					if sess.MaxFailedLogins > 0 {
-						maxFails = sess.MaxFailedLogins
-					}
-					if sess.TokenExpirationMinutes > 0 {
-						expireMinutes = sess.TokenExpirationMinutes
-					}
-				}
-			}
-		}
-	}
+	sessionPolicy := s.sessionPolicy(peakApp.ID, 720) // Default 12h
+	expireMinutes := sessionPolicy.TokenExpirationMinutes
 
-	if user.FailedLogins >= uint(maxFails) {
-		return "", 0, fmt.Errorf("cuenta bloqueada por exceso de intentos fallidos")
+	if err := s.checkLockout(user.ID, peakApp.ID); err != nil {
+		return "", 0, err
 	}
 
 	// 2. Verificar password
 	if !utils.CheckPasswordHash(password, user.Password) {
-		s.userRepo.UpdateColumn("failed_logins", user.FailedLogins+1, user.ID)
+		s.registerFailedLogin(user.ID, peakApp.ID, sessionPolicy)
 		return "", 0, fmt.Errorf("credenciales de administrador inválidas")
 	}
 
+	if !user.IsActive {
+		return "", 0, fmt.Errorf("usuario está desactivado")
+	}
+
 	// 3. Validar rol administrativo en Peak Auth Raíz
 	roleModels, err := s.uarRepo.FindRolesByUserAndApp(user.ID, peakApp.ID)
 	if err != nil || len(roleModels) == 0 {
@@ -416,11 +486,11 @@ func (s *userService) AdminLogin(email, password string) (string, int, error) {
 	}
 
 	// Limpiar fallos si todo ok
-	s.userRepo.UpdateColumn("failed_logins", 0, user.ID)
+	_ = s.lockoutRepo.Reset(user.ID, peakApp.ID)
 
 	// 4. Generar token con la duración de la política
 	// La regla SESSION_POLICY.TokenExpirationMinutes está en MINUTOS.
-	duration := time.Duration(expireMinutes) * time.Minute
+	duration := s.tokenDuration(user.ID, peakApp.ID, time.Duration(expireMinutes)*time.Minute)
 	
 	token, err := s.tokenManager.GenerateToken(user.ID, user.Email, peakApp.AppID, roles, duration)
 	if err != nil {
@@ -471,24 +541,17 @@ func (s *userService) Refresh(refreshToken string) (response.TokenResponse, erro
 		return response.TokenResponse{}, fmt.Errorf("usuario no encontrado")
 	}
 
+	if !user.IsActive {
+		return response.TokenResponse{}, fmt.Errorf("usuario está desactivado")
+	}
+
 	app, err := s.appRepo.FindByID(rt.ApplicationID)
 	if err != nil {
 		return response.TokenResponse{}, fmt.Errorf("aplicación no encontrada")
 	}
 
 	// 1. Duración según SESSION_POLICY
-	duration := time.Hour * 24
-	rules, err := s.ruleService.FindRulesByAppID(app.ID)
-	if err == nil {
-		for _, r := range rules {
-			if r.Code == "SESSION_POLICY" {
-				sess, err := utils.ParseSessionPolicy(r.Value)
-				if err == nil && sess.TokenExpirationMinutes > 0 {
-					duration = time.Duration(sess.TokenExpirationMinutes) * time.Minute
-				}
-			}
-		}
-	}
+	duration := time.Duration(s.sessionPolicy(app.ID, 24*60).TokenExpirationMinutes) * time.Minute
 
 	// 1.5 Obtener roles para el JWT
 	roleModels, _ := s.uarRepo.FindRolesByUserAndApp(user.ID, app.ID)
@@ -498,7 +561,8 @@ func (s *userService) Refresh(refreshToken string) (response.TokenResponse, erro
 	}
 
 	// 2. Generar nuevo Access Token
-	newAT, err := s.tokenManager.GenerateToken(user.ID, user.Email, app.AppID, roles, duration)
+	duration = s.tokenDuration(user.ID, app.ID, duration)
+	newAT, err := s.tokenManager.GenerateTokenWithExtras(user.ID, user.Email, app.AppID, roles, s.tokenExtras(user.ID, app.ID, roleModels), duration)
 	if err != nil {
 		return response.TokenResponse{}, err
 	}
@@ -509,7 +573,142 @@ func (s *userService) Refresh(refreshToken string) (response.TokenResponse, erro
 	}, nil
 }
 
-// UnlockUser resetea el contador de intentos fallidos
-func (s *userService) UnlockUser(userID uint) error {
-	return s.userRepo.UpdateColumn("failed_logins", 0, userID)
+// tokenDuration acota la vida del access token al vencimiento más próximo de los roles temporales
+// del usuario en la app, para que un rol vencido no siga en `roles`/`permissions` hasta el `exp`.
+func (s *userService) tokenDuration(userID, appID uint, duration time.Duration) time.Duration {
+	expiresAt, err := s.uarRepo.EarliestGrantExpiry(userID, appID)
+	if err != nil || expiresAt == nil {
+		return duration
+	}
+	if remaining := time.Until(*expiresAt); remaining < duration {
+		return remaining
+	}
+	return duration
+}
+
+// UnlockUser levanta el bloqueo y resetea el contador de intentos fallidos del usuario en la app
+func (s *userService) UnlockUser(userID, appID uint) error {
+	return s.lockoutRepo.Reset(userID, appID)
+}
+
+// ChangePassword cambia la contraseña de un usuario autenticado validando la actual
+// y la PWD_POLICY de la app del token. Opcionalmente revoca las demás sesiones.
+func (s *userService) ChangePassword(userID uint, publicAppID string, req request.ChangePasswordRequest) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado")
+	}
+
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return fmt.Errorf("aplicación no encontrada")
+	}
+
+	// Con un access token robado la contraseña actual se podría adivinar: los fallos cuentan
+	// para el bloqueo de la SESSION_POLICY igual que en el login
+	if err := s.checkLockout(user.ID, app.ID); err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		s.registerFailedLogin(user.ID, app.ID, s.sessionPolicy(app.ID, 24*60))
+		return fmt.Errorf("la contraseña actual es incorrecta")
+	}
+	_ = s.lockoutRepo.Reset(user.ID, app.ID)
+
+	if req.CurrentPassword == req.NewPassword {
+		return fmt.Errorf("la nueva contraseña debe ser distinta a la actual")
+	}
+
+	if err := s.ruleService.ValidatePassword(app.ID, req.NewPassword, user.Email, user.Profile.FirstName, user.Profile.LastName, app.Name); err != nil {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("error al hashear contraseña: %w", err)
+	}
+
+	if err := s.userRepo.UpdateColumn("password", hashed, user.ID); err != nil {
+		return fmt.Errorf("error al actualizar contraseña: %w", err)
+	}
+
+	if req.RevokeOtherSessions {
+		if req.RefreshToken != "" {
+			err = s.refreshTokenRepo.DeleteByUserExcept(user.ID, req.RefreshToken)
+		} else {
+			err = s.refreshTokenRepo.DeleteByUser(user.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("error al revocar sesiones: %w", err)
+		}
+	}
+
+	// El aviso no debe bloquear el cambio ya aplicado
+	go s.emailService.SendSecurityNotificationEmail(user.Email, SecurityEventPasswordChanged)
+
+	return nil
+}
+
+// CheckPasswordStrength estima la fortaleza de una contraseña para los medidores de los
+// formularios de registro, indicando si alcanza el min_score de la PWD_POLICY de la app.
+func (s *userService) CheckPasswordStrength(publicAppID string, req request.PasswordStrengthRequest) (response.PasswordStrengthResponse, error) {
+	userInputs := []string{req.Email, req.Username, req.FirstName, req.LastName}
+	minScore := 0
+
+	if publicAppID != "" {
+		app, err := s.appRepo.FindByAppID(publicAppID)
+		if err != nil {
+			return response.PasswordStrengthResponse{}, fmt.Errorf("aplicación no encontrada")
+		}
+		userInputs = append(userInputs, app.Name, app.AppID)
+
+		policy, err := s.ruleService.FindPasswordPolicy(app.ID)
+		if err != nil {
+			return response.PasswordStrengthResponse{}, fmt.Errorf("error al leer la política de contraseñas")
+		}
+		if policy != nil {
+			minScore = policy.MinScore
+		}
+	}
+
+	strength := utils.EstimatePasswordStrength(req.Password, userInputs...)
+	return response.PasswordStrengthResponse{
+		PasswordStrength: strength,
+		MinScore:         minScore,
+		Acceptable:       strength.Score >= minScore,
+	}, nil
+}
+
+// SearchUsers busca en el directorio global de usuarios y adjunta las apps de cada uno.
+func (s *userService) SearchUsers(filter request.UserDirectoryFilter) ([]response.UserDirectoryRow, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+
+	rows, total, err := s.userRepo.SearchDirectory(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al buscar usuarios: %w", err)
+	}
+
+	ids := make([]uint, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	apps, err := s.userRepo.FindAppsByUserIDs(ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error al cargar las aplicaciones de los usuarios: %w", err)
+	}
+
+	byUser := make(map[uint][]response.UserDirectoryApp, len(rows))
+	for _, a := range apps {
+		byUser[a.UserID] = append(byUser[a.UserID], a)
+	}
+	for i := range rows {
+		rows[i].Apps = byUser[rows[i].ID]
+	}
+
+	return rows, total, nil
 }
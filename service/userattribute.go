@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"peak-auth/auth"
+	"peak-auth/model"
+	"peak-auth/response"
+	"peak-auth/utils"
+)
+
+// tokenExtras arma los claims opcionales del access token: los atributos in_token de la app
+// y los permisos de los roles del usuario.
+func (s *userService) tokenExtras(userID, appID uint, roles []model.Role) auth.TokenExtras {
+	return auth.TokenExtras{
+		Attributes:  s.tokenAttributes(userID, appID),
+		Permissions: s.tokenPermissions(appID, roles),
+	}
+}
+
+// tokenAttributes devuelve los atributos in_token de la app. Si no se pueden leer,
+// el token se emite sin ellos (igual que con los roles).
+func (s *userService) tokenAttributes(userID, appID uint) map[string]interface{} {
+	schema, err := s.ruleService.FindAttributeSchema(appID)
+	if err != nil || len(schema.Attributes) == 0 {
+		return nil
+	}
+	values, err := s.attributeRepo.Find(userID, appID)
+	if err != nil {
+		return nil
+	}
+	return schema.TokenClaims(values)
+}
+
+// GetUserAttributes devuelve los atributos del usuario en la app con su esquema (consola).
+func (s *userService) GetUserAttributes(userID uint, publicAppID string) (response.UserAttributesView, error) {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.UserAttributesView{}, fmt.Errorf("aplicación no encontrada")
+	}
+	if _, err := s.userRepo.FindById(userID); err != nil {
+		return response.UserAttributesView{}, fmt.Errorf("usuario no encontrado")
+	}
+	schema, values, err := s.loadAttributes(userID, app.ID)
+	if err != nil {
+		return response.UserAttributesView{}, err
+	}
+	return attributesView(app.AppID, schema, values), nil
+}
+
+// UpdateUserAttributes aplica un PATCH de atributos como admin: puede cambiar cualquier
+// atributo del esquema y se exigen todos los obligatorios.
+func (s *userService) UpdateUserAttributes(userID uint, publicAppID string, changes map[string]interface{}) (response.UserAttributesView, error) {
+	app, err := s.appRepo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.UserAttributesView{}, fmt.Errorf("aplicación no encontrada")
+	}
+	if _, err := s.userRepo.FindById(userID); err != nil {
+		return response.UserAttributesView{}, fmt.Errorf("usuario no encontrado")
+	}
+	schema, values, err := s.applyAttributeChanges(userID, app.ID, changes, true)
+	if err != nil {
+		return response.UserAttributesView{}, err
+	}
+	return attributesView(app.AppID, schema, values), nil
+}
+
+// loadAttributes devuelve la ATTRIBUTE_SCHEMA de la app y los atributos guardados del usuario.
+func (s *userService) loadAttributes(userID, appID uint) (utils.AttributeSchema, map[string]interface{}, error) {
+	schema, err := s.ruleService.FindAttributeSchema(appID)
+	if err != nil {
+		return schema, nil, fmt.Errorf("error al leer ATTRIBUTE_SCHEMA: %w", err)
+	}
+	values, err := s.attributeRepo.Find(userID, appID)
+	if err != nil {
+		return schema, nil, fmt.Errorf("error al leer los atributos: %w", err)
+	}
+	return schema, values, nil
+}
+
+// applyAttributeChanges valida el PATCH contra la ATTRIBUTE_SCHEMA y lo persiste.
+func (s *userService) applyAttributeChanges(userID, appID uint, changes map[string]interface{}, byAdmin bool) (utils.AttributeSchema, map[string]interface{}, error) {
+	schema, current, err := s.loadAttributes(userID, appID)
+	if err != nil {
+		return schema, nil, err
+	}
+	if len(changes) == 0 {
+		return schema, current, nil
+	}
+
+	values, err := schema.ApplyChanges(current, changes, byAdmin)
+	if err != nil {
+		return schema, nil, err
+	}
+	if err := s.attributeRepo.Save(userID, appID, values); err != nil {
+		return schema, nil, fmt.Errorf("error al guardar los atributos: %w", err)
+	}
+	return schema, values, nil
+}
+
+func attributesView(appID string, schema utils.AttributeSchema, values map[string]interface{}) response.UserAttributesView {
+	definitions := schema.Attributes
+	if definitions == nil {
+		definitions = []utils.AttributeDefinition{}
+	}
+	return response.UserAttributesView{AppID: appID, Schema: definitions, Values: schema.Visible(values)}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"peak-auth/request"
+	"peak-auth/response"
+	"slices"
+	"strings"
+)
+
+const userDetailLoginAttempts = 25
+
+// GetUserDetail arma la ficha del usuario para la consola: perfil, roles en cada app,
+// bloqueos, sesiones activas e intentos de login recientes.
+func (s *userService) GetUserDetail(userID uint) (response.UserDetail, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return response.UserDetail{}, fmt.Errorf("usuario no encontrado")
+	}
+
+	detail := response.UserDetail{
+		ID:         user.ID,
+		Email:      user.Email,
+		FirstName:  user.Profile.FirstName,
+		LastName:   user.Profile.LastName,
+		BirthDate:  user.Profile.BirthDate,
+		AvatarURL:  user.Profile.AvatarURL,
+		IsVerified: user.IsVerified,
+		IsActive:   user.IsActive,
+		LastLogin:  user.LastLogin,
+		CreatedAt:  user.CreatedAt,
+
+		StatusReason:    user.StatusReason,
+		StatusChangedAt: user.StatusChangedAt,
+		StatusChangedBy: user.StatusChangedBy,
+	}
+	if user.Username != nil {
+		detail.Username = *user.Username
+	}
+
+	if detail.Apps, err = s.userRepo.FindAppsByUserIDs([]uint{user.ID}); err != nil {
+		return detail, fmt.Errorf("error al cargar las aplicaciones: %w", err)
+	}
+	for i, app := range detail.Apps {
+		effective, err := s.uarRepo.GetUserRolesInApp(user.ID, app.ApplicationID)
+		if err != nil {
+			return detail, fmt.Errorf("error al cargar los roles heredados: %w", err)
+		}
+		direct := strings.Split(app.RoleName, ", ")
+		for _, role := range effective {
+			if !slices.Contains(direct, role) {
+				detail.Apps[i].InheritedRoles = append(detail.Apps[i].InheritedRoles, role)
+			}
+		}
+	}
+	if detail.Lockouts, err = s.lockoutRepo.FindByUser(user.ID); err != nil {
+		return detail, fmt.Errorf("error al cargar los bloqueos: %w", err)
+	}
+	if detail.Sessions, err = s.refreshTokenRepo.FindActiveByUser(user.ID); err != nil {
+		return detail, fmt.Errorf("error al cargar las sesiones: %w", err)
+	}
+	if detail.LoginAttempts, err = s.loginAttemptRepo.FindRecentByUser(user.ID, userDetailLoginAttempts); err != nil {
+		return detail, fmt.Errorf("error al cargar la actividad: %w", err)
+	}
+	if detail.Impersonations, err = s.impersonationRepo.FindRecentByUser(user.ID, userDetailImpersonations); err != nil {
+		return detail, fmt.Errorf("error al cargar las suplantaciones: %w", err)
+	}
+	if deletion, err := s.accountDeletionRepo.FindPendingByUser(user.ID); err == nil {
+		detail.PendingDeletion = &deletion.ScheduledFor
+	}
+
+	return detail, nil
+}
+
+// ResendVerification reenvía el email de verificación a un usuario pendiente.
+func (s *userService) ResendVerification(userID uint) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado")
+	}
+	if user.IsVerified {
+		return fmt.Errorf("el usuario ya está verificado")
+	}
+
+	wait, err := s.verificationCooldown(user.ID)
+	if err != nil {
+		return fmt.Errorf("error al verificar el último envío: %w", err)
+	}
+	if wait > 0 {
+		return cooldownError(wait)
+	}
+	return s.sendVerificationEmail(user)
+}
+
+// SendResetEmailByID envía el email de restablecimiento de contraseña al usuario.
+func (s *userService) SendResetEmailByID(userID uint) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado")
+	}
+	return s.SendResetEmail(&user)
+}
+
+// RevokeAllSessions elimina todos los refresh tokens del usuario.
+func (s *userService) RevokeAllSessions(userID uint) error {
+	if err := s.refreshTokenRepo.DeleteByUser(userID); err != nil {
+		return fmt.Errorf("error al revocar sesiones: %w", err)
+	}
+	return nil
+}
+
+// UnlockAll levanta los bloqueos del usuario en todas las aplicaciones.
+func (s *userService) UnlockAll(userID uint) error {
+	if err := s.lockoutRepo.ResetAll(userID); err != nil {
+		return fmt.Errorf("error al desbloquear al usuario: %w", err)
+	}
+	return nil
+}
+
+// SetUserStatus activa o desactiva al usuario de forma global. La desactivación exige
+// un motivo y corta sus sesiones: refresh tokens eliminados y access tokens revocados.
+func (s *userService) SetUserStatus(actorID, userID uint, req request.UserRequest) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado")
+	}
+
+	if user.IsActive == req.IsActive {
+		if req.IsActive {
+			return fmt.Errorf("el usuario ya está activo")
+		}
+		return fmt.Errorf("el usuario ya está desactivado")
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if !req.IsActive {
+		if reason == "" {
+			return fmt.Errorf("indica el motivo de la desactivación")
+		}
+		if user.ID == actorID {
+			return fmt.Errorf("no puedes desactivar tu propia cuenta")
+		}
+		if isRoot, _ := s.uarRepo.HasRole(user.ID, "ROOT"); isRoot {
+			return fmt.Errorf("un usuario ROOT no puede ser desactivado")
+		}
+	}
+
+	if err := s.userRepo.UpdateStatus(user.ID, req.IsActive, reason, actorID); err != nil {
+		return fmt.Errorf("error al actualizar el estado del usuario: %w", err)
+	}
+	return nil
+}
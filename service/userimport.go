@@ -0,0 +1,330 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"peak-auth/model"
+	"peak-auth/repository"
+	"peak-auth/request"
+	"peak-auth/response"
+	"peak-auth/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userImportPlan es una fila validada junto con lo que hay que aplicar.
+type userImportPlan struct {
+	row      request.UserImportRow
+	result   *response.UserImportRowResult
+	existing *model.User
+	roles    []model.Role // Roles que faltan asignar en la app
+}
+
+// activation es un email de activación pendiente de enviar tras la importación.
+type activation struct {
+	email string
+	token string
+}
+
+// ImportUsers valida y, si no es dry-run y ninguna fila tiene errores, importa los usuarios
+// en la app en una única transacción. A los usuarios existentes sólo se les agregan los
+// roles que falten: su perfil, contraseña y verificación no se modifican. Los emails sin
+// cuenta ni contraseña no crean cuenta: reciben una invitación a la app con sus roles.
+func (s *applicationService) ImportUsers(publicAppID string, rows []request.UserImportRow, opts request.UserImportOptions) (response.UserImportReport, error) {
+	app, err := s.repo.FindByAppID(publicAppID)
+	if err != nil {
+		return response.UserImportReport{}, fmt.Errorf("aplicación no encontrada")
+	}
+
+	report := response.UserImportReport{DryRun: opts.DryRun, Total: len(rows)}
+	report.Rows = make([]response.UserImportRowResult, len(rows))
+
+	plans, err := s.planUserImport(app, rows, opts, report.Rows)
+	if err != nil {
+		return report, err
+	}
+
+	for _, r := range report.Rows {
+		switch r.Status {
+		case response.UserImportCreated:
+			report.Created++
+		case response.UserImportInvited:
+			report.Invited++
+		case response.UserImportLinked:
+			report.Linked++
+		case response.UserImportUnchanged:
+			report.Unchanged++
+		case response.UserImportFailed:
+			report.Failed++
+		}
+		if r.Activation {
+			report.Activations++
+		}
+	}
+
+	if opts.DryRun || report.Failed > 0 {
+		return report, nil
+	}
+
+	var activations []activation
+	err = s.txManager.WithinTransaction(func(tx repository.TxRepository) error {
+		activations = nil
+		for _, plan := range plans {
+			if plan.result.Status == response.UserImportInvited {
+				continue
+			}
+			pending, err := applyUserImport(tx, app, plan)
+			if err != nil {
+				return fmt.Errorf("línea %d (%s): %w", plan.row.Line, plan.row.Email, err)
+			}
+			if pending != nil {
+				activations = append(activations, *pending)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("error al importar usuarios: %w", err)
+	}
+	report.Applied = true
+
+	// Envío asíncrono para no demorar la respuesta del panel
+	for _, a := range activations {
+		go s.emailService.SendVerificationEmail(a.email, a.token)
+	}
+
+	// Las invitaciones se crean (y envían) una vez confirmada la transacción, para no mandar
+	// links de una importación que no se aplicó
+	var failed []string
+	for _, plan := range plans {
+		if plan.result.Status != response.UserImportInvited {
+			continue
+		}
+		if err := s.inviteUser(app, plan.row.Email, plan.roles, opts.ImportedBy); err != nil {
+			plan.result.Errors = append(plan.result.Errors, err.Error())
+			failed = append(failed, fmt.Sprintf("línea %d (%s)", plan.row.Line, plan.row.Email))
+		}
+	}
+	if len(failed) > 0 {
+		return report, fmt.Errorf("los usuarios se importaron pero fallaron las invitaciones de: %s", strings.Join(failed, ", "))
+	}
+	return report, nil
+}
+
+// planUserImport valida cada fila y decide si crea, vincula o deja sin cambios al usuario.
+func (s *applicationService) planUserImport(app model.Application, rows []request.UserImportRow, opts request.UserImportOptions, results []response.UserImportRowResult) ([]userImportPlan, error) {
+	roleCache := map[string]*model.Role{}
+	seen := map[string]int{}
+	seenUsernames := map[string]int{}
+	plans := make([]userImportPlan, 0, len(rows))
+
+	for i, row := range rows {
+		row.Email = utils.NormalizeEmail(row.Email)
+		row.FirstName = strings.TrimSpace(row.FirstName)
+		row.LastName = strings.TrimSpace(row.LastName)
+		// La contraseña y la verificación crean una cuenta global lista para usar en cualquier
+		// app: sólo se aceptan de ROOT. Para el resto, los emails sin cuenta reciben una invitación.
+		if !opts.ByRoot {
+			row.PasswordHash, row.Verified = "", false
+		}
+
+		result := &results[i]
+		*result = response.UserImportRowResult{Line: row.Line, Email: row.Email}
+		fail := func(format string, args ...interface{}) {
+			result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+		}
+
+		if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email || len(row.Email) > 100 {
+			fail("email inválido")
+		} else if line, dup := seen[row.Email]; dup {
+			fail("email repetido (línea %d)", line)
+		} else {
+			seen[row.Email] = row.Line
+		}
+		if len(row.FirstName) > 50 || len(row.LastName) > 50 {
+			fail("nombre y apellido admiten hasta 50 caracteres")
+		}
+		if row.PasswordHash != "" && !utils.IsPasswordHash(row.PasswordHash) {
+			fail("password_hash no es un hash bcrypt válido")
+		}
+
+		var roles []model.Role
+		if len(row.Roles) == 0 {
+			fail("debe indicar al menos un rol")
+		}
+		for _, name := range row.Roles {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if name == "ROOT" {
+				fail("el rol ROOT no se puede importar")
+				continue
+			}
+			if name == utils.RoleImpersonator && !opts.ByRoot {
+				fail("sólo ROOT puede otorgar el rol %s", utils.RoleImpersonator)
+				continue
+			}
+			role, ok := roleCache[name]
+			if !ok {
+				found, err := s.roleRepo.FindByNameForApp(app.ID, name)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, fmt.Errorf("error al buscar el rol %s: %w", name, err)
+				}
+				if err == nil {
+					role = &found
+				}
+				roleCache[name] = role
+			}
+			if role == nil {
+				fail("el rol %s no existe", name)
+				continue
+			}
+			roles = append(roles, *role)
+		}
+
+		if len(result.Errors) > 0 {
+			result.Status = response.UserImportFailed
+			continue
+		}
+
+		plan := userImportPlan{row: row, result: result}
+		user, err := s.userRepo.FindByEmail(row.Email)
+		switch {
+		case err == nil:
+			current, err := s.uarRepo.GetUserRolesInApp(user.ID, app.ID)
+			if err != nil {
+				return nil, fmt.Errorf("error al leer los roles de %s: %w", row.Email, err)
+			}
+			plan.existing = &user
+			plan.roles = missingRoles(roles, current)
+			result.Status = response.UserImportLinked
+			if len(plan.roles) == 0 {
+				result.Status = response.UserImportUnchanged
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			plan.roles = uniqueRoles(roles)
+			if row.PasswordHash == "" {
+				// Sin contraseña no se crea la cuenta: el usuario la crea al aceptar la invitación
+				result.Status = response.UserImportInvited
+				break
+			}
+			result.Status = response.UserImportCreated
+			// Sin verificar, el usuario necesita el link de activación
+			result.Activation = opts.SendInvitations && !row.Verified
+			// El username sólo se asigna a las cuentas que crea la importación: el invitado lo
+			// elige al aceptar y los usuarios existentes lo cambian desde su perfil
+			if row.Username == "" {
+				break
+			}
+			username, err := checkUsername(s.userRepo, row.Username)
+			if err == nil {
+				if line, dup := seenUsernames[username]; dup {
+					err = fmt.Errorf("username repetido (línea %d)", line)
+				}
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				result.Status = response.UserImportFailed
+				continue
+			}
+			seenUsernames[username] = row.Line
+			plan.row.Username = username
+		default:
+			return nil, fmt.Errorf("error al buscar el usuario %s: %w", row.Email, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// applyUserImport crea o vincula al usuario de una fila y, si corresponde, genera su link de
+// activación.
+func applyUserImport(tx repository.TxRepository, app model.Application, plan userImportPlan) (*activation, error) {
+	user := plan.existing
+	if user == nil {
+		user = &model.User{
+			Email:      plan.row.Email,
+			Password:   plan.row.PasswordHash,
+			IsVerified: plan.row.Verified,
+		}
+		if plan.row.Username != "" {
+			user.Username = &plan.row.Username
+		}
+		profile := model.Profile{FirstName: plan.row.FirstName, LastName: plan.row.LastName}
+		if profile.FirstName == "" && profile.LastName == "" {
+			profile.FirstName, profile.LastName = "Usuario", "Invitado"
+		}
+		if err := tx.Users().CreateWithProfile(user, &profile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, role := range plan.roles {
+		if err := tx.UAR().AssignRole(user.ID, app.ID, role.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !plan.result.Activation {
+		return nil, nil
+	}
+	plainToken, hashedToken, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	reset := model.PasswordReset{
+		UserID:        user.ID,
+		ApplicationID: app.ID,
+		TokenHash:     hashedToken,
+		ExpiresAt:     time.Now().Add(24 * time.Hour),
+	}
+	if err := tx.PasswordResets().CreatePasswordReset(&reset); err != nil {
+		return nil, err
+	}
+	return &activation{email: user.Email, token: plainToken}, nil
+}
+
+// missingRoles devuelve los roles que el usuario todavía no tiene en la app.
+func missingRoles(roles []model.Role, current []string) []model.Role {
+	has := map[string]bool{}
+	for _, name := range current {
+		has[name] = true
+	}
+	var missing []model.Role
+	for _, role := range uniqueRoles(roles) {
+		if !has[role.Name] {
+			missing = append(missing, role)
+		}
+	}
+	return missing
+}
+
+func uniqueRoles(roles []model.Role) []model.Role {
+	seen := map[uint]bool{}
+	var unique []model.Role
+	for _, role := range roles {
+		if !seen[role.ID] {
+			seen[role.ID] = true
+			unique = append(unique, role)
+		}
+	}
+	return unique
+}
+
+// ExportUsers devuelve los usuarios de la app con sus roles.
+func (s *applicationService) ExportUsers(publicAppID string) ([]response.UserExportRow, error) {
+	app, err := s.repo.FindByAppID(publicAppID)
+	if err != nil {
+		return nil, fmt.Errorf("aplicación no encontrada")
+	}
+
+	rows, err := s.uarRepo.ExportByApp(app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error al exportar usuarios: %w", err)
+	}
+	for i := range rows {
+		rows[i].Roles = strings.Split(rows[i].RoleNames, "|")
+	}
+	return rows, nil
+}
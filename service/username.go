@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+	"peak-auth/model"
+	"peak-auth/repository"
+	"peak-auth/utils"
+
+	"gorm.io/gorm"
+)
+
+// findLoginUser busca al usuario por email o por username según el identificador y la
+// LOGIN_POLICY de la app. Un identificador no permitido se trata como usuario inexistente
+// para no revelar qué cuentas existen.
+func (s *userService) findLoginUser(appID uint, identifier string) (model.User, error) {
+	policy, err := s.ruleService.FindLoginPolicy(appID)
+	if err != nil {
+		return model.User{}, fmt.Errorf("error al leer LOGIN_POLICY: %w", err)
+	}
+
+	if utils.IsEmailIdentifier(identifier) {
+		if !policy.Allows(utils.LoginIdentifierEmail) {
+			return model.User{}, gorm.ErrRecordNotFound
+		}
+		return s.userRepo.FindByEmail(utils.NormalizeEmail(identifier))
+	}
+
+	if !policy.Allows(utils.LoginIdentifierUsername) {
+		return model.User{}, gorm.ErrRecordNotFound
+	}
+	return s.userRepo.FindByUsername(utils.NormalizeUsername(identifier))
+}
+
+// checkUsername normaliza y valida un username nuevo y comprueba que esté libre.
+func checkUsername(users repository.UserRepository, username string) (string, error) {
+	normalized := utils.NormalizeUsername(username)
+	if err := utils.ValidateUsername(normalized); err != nil {
+		return "", err
+	}
+	taken, err := users.ExistsByUsername(normalized)
+	if err != nil {
+		return "", fmt.Errorf("error verificando el nombre de usuario: %w", err)
+	}
+	if taken {
+		return "", fmt.Errorf("el nombre de usuario ya está en uso")
+	}
+	return normalized, nil
+}
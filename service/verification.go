@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"peak-auth/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// verificationResendCooldown es el tiempo mínimo entre dos emails de verificación.
+const verificationResendCooldown = 5 * time.Minute
+
+// verificationCooldown devuelve cuánto falta para poder reenviar la verificación al usuario.
+func (s *userService) verificationCooldown(userID uint) (time.Duration, error) {
+	last, err := s.emailVerificationRepo.FindLastCreatedAt(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if wait := time.Until(last.Add(verificationResendCooldown)); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// ResendVerificationByEmail reenvía el link de verificación a un usuario pendiente.
+// Para no revelar qué emails están registrados todo el trabajo corre en segundo plano:
+// la respuesta (y su demora) es la misma exista o no la cuenta, y los errores sólo se loguean.
+func (s *userService) ResendVerificationByEmail(email string) {
+	go func() {
+		user, err := s.userRepo.FindByEmail(utils.NormalizeEmail(email))
+		if err != nil || user.IsVerified || !user.IsActive {
+			return
+		}
+
+		wait, err := s.verificationCooldown(user.ID)
+		if err != nil {
+			log.Printf("error al verificar el último envío de verificación del usuario %d: %v", user.ID, err)
+			return
+		}
+		if wait > 0 {
+			return
+		}
+		if err := s.sendVerificationEmail(user); err != nil {
+			log.Printf("error reenviando la verificación al usuario %d: %v", user.ID, err)
+		}
+	}()
+}
+
+// cooldownError arma el mensaje de espera para la consola.
+func cooldownError(wait time.Duration) error {
+	minutes := int(math.Ceil(wait.Minutes()))
+	return fmt.Errorf("ya se envió un email de verificación recientemente, esperá %d minuto(s)", minutes)
+}
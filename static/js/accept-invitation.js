@@ -0,0 +1,45 @@
+/**
+ * Maneja la aceptación de una invitación
+ */
+async function handleAcceptInvitation(e) {
+    e.preventDefault();
+    const form = e.target;
+    const btn = form.querySelector('button[type="submit"]');
+    btn.disabled = true;
+
+    try {
+        const response = await fetch('/api/v1/invitations/accept', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+            body: new URLSearchParams(new FormData(form))
+        });
+        const text = await response.text();
+
+        if (response.ok) {
+            await Swal.fire({
+                title: 'Éxito',
+                text: text,
+                icon: 'success',
+                confirmButtonColor: '#4f46e5'
+            });
+            form.reset();
+            window.location.href = "/admin/login";
+        } else {
+            Swal.fire({
+                title: 'Error',
+                text: text,
+                icon: 'error',
+                confirmButtonColor: '#4f46e5'
+            });
+        }
+    } catch (err) {
+        Swal.fire({
+            title: 'Error de conexión',
+            text: 'No se pudo conectar con el servidor',
+            icon: 'error',
+            confirmButtonColor: '#4f46e5'
+        });
+    } finally {
+        btn.disabled = false;
+    }
+}
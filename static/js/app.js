@@ -161,16 +161,17 @@ async function confirmDeleteApp() {
  * Guarda una regla
  * @param {*} code 
  * @param {*} data 
- * @returns 
+ * @param {*} method PUT para actualizar (por defecto) o POST para crearla
+ * @returns true si se guardó
  */
-async function saveRule(code, data) {
+async function saveRule(code, data, method = 'PUT') {
     if (!window.appID) {
         console.error("window.appID no definido");
         return;
     }
     try {
         const response = await fetch(`/admin/apps/${window.appID}/rules/${code}`, {
-            method: 'PUT',
+            method: method,
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(data)
         });
@@ -184,9 +185,11 @@ async function saveRule(code, data) {
         }
 
         showToast('Guardado automáticamente');
+        return true;
     } catch (err) {
         console.error(err);
         peakAlert('Error', err.message, 'error');
+        return false;
     }
 }
 
@@ -209,7 +212,8 @@ function updatePassword() {
         min_length: parseInt(document.getElementById('pwd_min_length').value) || 8,
         require_uppercase: document.getElementById('pwd_require_uppercase').checked,
         require_numbers: document.getElementById('pwd_require_numbers').checked,
-        require_symbols: document.getElementById('pwd_require_symbols').checked
+        require_symbols: document.getElementById('pwd_require_symbols').checked,
+        min_score: parseInt(document.getElementById('pwd_min_score').value) || 0
     });
 }
 
@@ -219,7 +223,13 @@ function updatePassword() {
 function updateSession() {
     saveRule('SESSION_POLICY', {
         token_expiration_minutes: parseInt(document.getElementById('session_expiration').value) || 1440,
-        max_failed_logins: parseInt(document.getElementById('session_max_failed').value) || 5
+        max_failed_logins: parseInt(document.getElementById('session_max_failed').value) || 5,
+        lockout_minutes: parseInt(document.getElementById('session_lockout_minutes').value) || 15,
+        max_lockout_minutes: parseInt(document.getElementById('session_max_lockout_minutes').value) || 1440,
+        challenge_after_failures: parseInt(document.getElementById('session_challenge_failures').value) || 0,
+        challenge_after_ip_failures: parseInt(document.getElementById('session_challenge_ip_failures').value) || 0,
+        challenge_difficulty: parseInt(document.getElementById('session_challenge_difficulty').value) || 0,
+        challenge_type: document.getElementById('session_challenge_type').value
     });
 }
 
@@ -242,3 +252,137 @@ function updateAuthz() {
     });
 }
 
+/**
+ * Actualiza los identificadores aceptados en el login (LOGIN_POLICY).
+ * Si la app todavía no tiene la regla, la crea.
+ */
+async function updateLoginPolicy(checkbox) {
+    const wrapper = document.getElementById('login_identifiers');
+    const identifiers = [];
+    if (document.getElementById('login_allow_email').checked) identifiers.push('email');
+    if (document.getElementById('login_allow_username').checked) identifiers.push('username');
+
+    if (identifiers.length === 0) {
+        checkbox.checked = true;
+        togglePill(checkbox, checkbox.dataset.label, 'indigo');
+        peakAlert('Atención', 'El login debe aceptar al menos un identificador', 'warning');
+        return;
+    }
+
+    const exists = wrapper.dataset.exists === 'true';
+    if (await saveRule('LOGIN_POLICY', { identifiers }, exists ? 'PUT' : 'POST')) {
+        wrapper.dataset.exists = 'true';
+    }
+}
+
+/**
+ * Guarda el esquema de atributos personalizados (ATTRIBUTE_SCHEMA).
+ * Si la app todavía no tiene la regla, la crea.
+ */
+async function updateAttributeSchema() {
+    const textarea = document.getElementById('attr_schema');
+    let schema;
+    try {
+        schema = JSON.parse(textarea.value || '{"attributes": []}');
+    } catch (e) {
+        peakAlert('Error', 'El esquema no es un JSON válido', 'error');
+        return;
+    }
+
+    const exists = textarea.dataset.exists === 'true';
+    try {
+        const response = await fetch(`/admin/apps/${window.appID}/rules/ATTRIBUTE_SCHEMA`, {
+            method: exists ? 'PUT' : 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(schema)
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || 'Error al guardar el esquema');
+        }
+
+        showToast('Esquema de atributos guardado');
+        setTimeout(() => window.location.reload(), 800);
+    } catch (err) {
+        peakAlert('Error', err.message, 'error');
+    }
+}
+
+/**
+ * Agrega un permiso al catálogo de la aplicación.
+ */
+async function createPermission(event) {
+    event.preventDefault();
+    const form = event.target;
+    try {
+        const response = await fetch(`/admin/apps/${window.appID}/permissions`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                code: form.code.value.trim(),
+                description: form.description.value.trim()
+            })
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || 'No se pudo crear el permiso');
+        }
+
+        showToast(data.message);
+        setTimeout(() => window.location.reload(), 800);
+    } catch (err) {
+        peakAlert('Error', err.message, 'error');
+    }
+}
+
+/**
+ * Elimina un permiso del catálogo (y de los roles que lo tenían).
+ */
+async function deletePermission(permissionID, code) {
+    const confirmed = await peakConfirm({
+        title: `¿Eliminar el permiso "${code}"?`,
+        text: 'Se quitará de todos los roles. Los tokens nuevos ya no lo incluirán.',
+        confirmText: 'Sí, eliminar',
+        type: 'danger'
+    });
+    if (!confirmed) return;
+
+    try {
+        const response = await fetch(`/admin/apps/${window.appID}/permissions/${permissionID}`, {
+            method: 'DELETE'
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || 'No se pudo eliminar el permiso');
+        }
+
+        showToast(data.message);
+        setTimeout(() => window.location.reload(), 800);
+    } catch (err) {
+        peakAlert('Error', err.message, 'error');
+    }
+}
+
+/**
+ * Guarda los permisos marcados para un rol.
+ */
+async function updateRolePermissions(roleName) {
+    const row = document.querySelector(`tr[data-role="${roleName}"]`);
+    const permissions = Array.from(row.querySelectorAll('input[type="checkbox"]:checked')).map(cb => cb.value);
+
+    try {
+        const response = await fetch(`/admin/apps/${window.appID}/roles/${encodeURIComponent(roleName)}/permissions`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ permissions })
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || 'No se pudieron guardar los permisos');
+        }
+
+        showToast('Guardado automáticamente');
+    } catch (err) {
+        peakAlert('Error', err.message, 'error');
+    }
+}
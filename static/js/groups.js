@@ -0,0 +1,248 @@
+/**
+ * groups.js - Gestión de grupos de usuarios en la consola.
+ */
+
+// Envía una petición JSON al endpoint de grupos y devuelve { ok, data }
+async function groupRequest(url, method, body) {
+    const response = await fetch(url, {
+        method: method,
+        headers: { 'Content-Type': 'application/json' },
+        body: body ? JSON.stringify(body) : undefined
+    });
+    const data = await response.json();
+    return { ok: response.ok, data };
+}
+
+// Crear un grupo y abrir su ficha
+async function createGroup(event) {
+    event.preventDefault();
+    const name = document.getElementById('groupName').value.trim();
+    const description = document.getElementById('groupDescription').value.trim();
+
+    try {
+        const { ok, data } = await groupRequest('/admin/groups', 'POST', { name, description });
+        if (ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.href = `/admin/groups/${data.id}`, 800);
+        } else {
+            peakAlert('No se pudo crear', data.error, 'warning');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Renombrar el grupo o cambiar su descripción
+async function editGroup(groupID, name, description) {
+    const isDark = document.documentElement.classList.contains('dark');
+    const inputClass = 'w-full px-4 py-3 border border-slate-200 rounded-xl outline-none text-slate-900';
+    const result = await Swal.fire({
+        title: 'Editar grupo',
+        html: `<label class="block text-left mb-3">
+                    <span class="block text-xs font-bold text-slate-500 mb-1">Nombre</span>
+                    <input type="text" id="editGroupName" maxlength="100" class="${inputClass}">
+                </label>
+                <label class="block text-left">
+                    <span class="block text-xs font-bold text-slate-500 mb-1">Descripción</span>
+                    <input type="text" id="editGroupDescription" maxlength="255" class="${inputClass}">
+                </label>`,
+        didOpen: () => {
+            document.getElementById('editGroupName').value = name;
+            document.getElementById('editGroupDescription').value = description;
+        },
+        showCancelButton: true,
+        confirmButtonText: 'Guardar',
+        cancelButtonText: 'Cancelar',
+        confirmButtonColor: '#0284c7',
+        cancelButtonColor: '#64748b',
+        background: isDark ? '#1e293b' : '#fff',
+        color: isDark ? '#f8fafc' : '#0f172a',
+        reverseButtons: true,
+        customClass: {
+            popup: 'rounded-3xl',
+            confirmButton: 'rounded-xl font-bold',
+            cancelButton: 'rounded-xl font-bold'
+        },
+        preConfirm: () => {
+            const value = {
+                name: document.getElementById('editGroupName').value.trim(),
+                description: document.getElementById('editGroupDescription').value.trim()
+            };
+            if (!value.name) {
+                Swal.showValidationMessage('El nombre es obligatorio');
+                return false;
+            }
+            return value;
+        }
+    });
+    if (!result.isConfirmed) return;
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}`, 'PUT', result.value);
+        if (ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('No se pudo guardar', data.error, 'warning');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Eliminar el grupo (sus miembros pierden los roles concedidos por él)
+async function deleteGroup(groupID, name) {
+    const confirmed = await peakConfirm({
+        title: `¿Eliminar el grupo "${name}"?`,
+        text: 'Sus miembros perderán los roles que recibían a través del grupo.',
+        confirmText: 'Sí, eliminar',
+        type: 'danger'
+    });
+    if (!confirmed) return;
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}`, 'DELETE');
+        if (ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.href = '/admin/groups', 800);
+        } else {
+            peakAlert('No se pudo eliminar', data.error, 'warning');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Alta masiva de miembros a partir de una lista de emails
+async function addGroupMembers(event, groupID) {
+    event.preventDefault();
+    const emails = document.getElementById('memberEmails').value
+        .split(/[\n,;]+/)
+        .map((email) => email.trim())
+        .filter((email) => email !== '');
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}/members`, 'POST', { emails });
+        if (!ok) {
+            peakAlert('No se pudieron agregar', data.error, 'warning');
+            return;
+        }
+        reportGroupMembers(data);
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Muestra el resultado del alta masiva y recarga la página
+async function reportGroupMembers(result) {
+    const summary = `Agregados: ${result.added}. Ya eran miembros: ${result.skipped}.`;
+    if (result.not_found.length === 0) {
+        showToast(summary);
+        setTimeout(() => window.location.reload(), 800);
+        return;
+    }
+
+    const isDark = document.documentElement.classList.contains('dark');
+    await Swal.fire({
+        title: 'Alta parcial',
+        text: `${summary} Sin usuario: ${result.not_found.join(', ')}.`,
+        icon: 'warning',
+        confirmButtonText: 'Entendido',
+        confirmButtonColor: '#f59e0b',
+        background: isDark ? '#1e293b' : '#fff',
+        color: isDark ? '#f8fafc' : '#0f172a',
+        customClass: {
+            popup: 'rounded-3xl',
+            confirmButton: 'rounded-xl font-bold'
+        }
+    });
+    window.location.reload();
+}
+
+// Quitar un usuario del grupo
+async function removeGroupMember(groupID, userID, email) {
+    const confirmed = await peakConfirm({
+        title: `¿Quitar a ${email} del grupo?`,
+        text: 'Perderá los roles que recibía a través del grupo.',
+        confirmText: 'Sí, quitar',
+        type: 'danger'
+    });
+    if (!confirmed) return;
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}/members/${userID}`, 'DELETE');
+        if (ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('Error', data.error, 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Conceder un rol al grupo en una aplicación
+async function grantGroupRole(event, groupID) {
+    event.preventDefault();
+    const body = {
+        app_id: document.getElementById('grantApp').value,
+        role: document.getElementById('grantRole').value.trim().toUpperCase()
+    };
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}/roles`, 'POST', body);
+        if (ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('No se pudo conceder', data.error, 'warning');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Quitar un rol concedido al grupo
+async function revokeGroupRole(groupID, appID, role) {
+    const confirmed = await peakConfirm({
+        title: `¿Quitar el rol ${role}?`,
+        text: 'Los miembros dejarán de recibirlo a través del grupo.',
+        confirmText: 'Sí, quitar',
+        type: 'danger'
+    });
+    if (!confirmed) return;
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}/roles`, 'DELETE', { app_id: appID, role });
+        if (ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('Error', data.error, 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
+// Agregar al grupo elegido los usuarios marcados en el directorio
+async function addSelectedToGroup() {
+    const groupID = document.getElementById('bulkGroup').value;
+    const userIDs = [...document.querySelectorAll('.user-select:checked')].map((el) => Number(el.value));
+    if (!groupID || userIDs.length === 0) {
+        peakAlert('Faltan datos', 'Elige un grupo y al menos un usuario', 'info');
+        return;
+    }
+
+    try {
+        const { ok, data } = await groupRequest(`/admin/groups/${groupID}/members`, 'POST', { user_ids: userIDs });
+        if (!ok) {
+            peakAlert('No se pudieron agregar', data.error, 'warning');
+            return;
+        }
+        reportGroupMembers(data);
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
@@ -0,0 +1,44 @@
+/**
+ * Cierra la sesión indicada en el link del aviso de nuevo dispositivo
+ */
+async function handleRevokeSession(e) {
+    e.preventDefault();
+    const form = e.target;
+    const btn = form.querySelector('button[type="submit"]');
+    btn.disabled = true;
+
+    try {
+        const response = await fetch('/api/v1/sessions/revoke', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+            body: new URLSearchParams(new FormData(form))
+        });
+        const text = await response.text();
+
+        if (response.ok) {
+            await Swal.fire({
+                title: 'Sesión cerrada',
+                text: text,
+                icon: 'success',
+                confirmButtonColor: '#4f46e5'
+            });
+            btn.classList.add('hidden');
+        } else {
+            Swal.fire({
+                title: 'Error',
+                text: text,
+                icon: 'error',
+                confirmButtonColor: '#4f46e5'
+            });
+            btn.disabled = false;
+        }
+    } catch (err) {
+        Swal.fire({
+            title: 'Error de conexión',
+            text: 'No se pudo conectar con el servidor',
+            icon: 'error',
+            confirmButtonColor: '#4f46e5'
+        });
+        btn.disabled = false;
+    }
+}
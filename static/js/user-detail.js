@@ -0,0 +1,142 @@
+/**
+ * user-detail.js - Acciones de la ficha de usuario en la consola.
+ */
+
+// Ejecutar una acción sobre el usuario (reenviar verificación, reset, revocar sesiones, desbloquear)
+async function userAction(userID, action, question) {
+    const confirmed = await peakConfirm({
+        title: question,
+        text: 'La acción se aplicará de inmediato.',
+        confirmText: 'Sí, continuar'
+    });
+    if (!confirmed) return;
+
+    try {
+        const response = await fetch(`/admin/users/${userID}/${action}`, {
+            method: 'POST'
+        });
+        const data = await response.json();
+
+        if (response.ok) {
+            showToast(data.message || 'Acción realizada');
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('Error', data.error || 'No se pudo completar la acción', 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    }
+}
+
+// Activar o desactivar al usuario de forma global (la desactivación pide un motivo)
+async function setUserStatus(userID, active) {
+    const isDark = document.documentElement.classList.contains('dark');
+    const result = await Swal.fire({
+        title: active ? '¿Reactivar al usuario?' : '¿Desactivar al usuario?',
+        text: active
+            ? 'Podrá volver a iniciar sesión en todas las aplicaciones.'
+            : 'Se cerrarán todas sus sesiones y no podrá iniciar sesión en ninguna aplicación.',
+        input: 'textarea',
+        inputPlaceholder: active ? 'Motivo (opcional)' : 'Motivo de la desactivación',
+        inputAttributes: { maxlength: 255 },
+        inputValidator: (value) => {
+            if (!active && !value.trim()) return 'El motivo es obligatorio';
+        },
+        icon: 'warning',
+        showCancelButton: true,
+        confirmButtonText: active ? 'Sí, reactivar' : 'Sí, desactivar',
+        cancelButtonText: 'Cancelar',
+        confirmButtonColor: active ? '#10b981' : '#e11d48',
+        cancelButtonColor: '#64748b',
+        background: isDark ? '#1e293b' : '#fff',
+        color: isDark ? '#f8fafc' : '#0f172a',
+        reverseButtons: true,
+        customClass: {
+            popup: 'rounded-3xl',
+            confirmButton: 'rounded-xl font-bold',
+            cancelButton: 'rounded-xl font-bold'
+        }
+    });
+    if (!result.isConfirmed) return;
+
+    try {
+        const response = await fetch(`/admin/users/${userID}/status`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ is_active: active, reason: result.value.trim() })
+        });
+        const data = await response.json();
+
+        if (response.ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('Error', data.error || 'No se pudo cambiar el estado', 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    }
+}
+
+// Suplantar al usuario en una aplicación: pide el motivo y muestra el token de corta duración
+async function impersonateUser(userID, appID, appName) {
+    const isDark = document.documentElement.classList.contains('dark');
+    const result = await Swal.fire({
+        title: `¿Suplantar al usuario en ${appName}?`,
+        text: 'Se emitirá un token de 15 minutos a tu nombre. Queda registrado y el usuario recibirá un aviso por email.',
+        input: 'textarea',
+        inputPlaceholder: 'Motivo (p. ej. número de ticket)',
+        inputAttributes: { maxlength: 255 },
+        inputValidator: (value) => {
+            if (!value.trim()) return 'El motivo es obligatorio';
+        },
+        icon: 'warning',
+        showCancelButton: true,
+        confirmButtonText: 'Sí, suplantar',
+        cancelButtonText: 'Cancelar',
+        confirmButtonColor: '#d97706',
+        cancelButtonColor: '#64748b',
+        background: isDark ? '#1e293b' : '#fff',
+        color: isDark ? '#f8fafc' : '#0f172a',
+        reverseButtons: true,
+        customClass: {
+            popup: 'rounded-3xl',
+            confirmButton: 'rounded-xl font-bold',
+            cancelButton: 'rounded-xl font-bold'
+        }
+    });
+    if (!result.isConfirmed) return;
+
+    try {
+        const response = await fetch(`/admin/users/${userID}/impersonate`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ app_id: appID, reason: result.value.trim() })
+        });
+        const data = await response.json();
+
+        if (!response.ok) {
+            peakAlert('Error', data.error || 'No se pudo iniciar la suplantación', 'error');
+            return;
+        }
+
+        const expires = new Date(data.expires_at).toLocaleTimeString();
+        await Swal.fire({
+            title: 'Token de suplantación',
+            html: `<p class="text-sm mb-3">Válido hasta las ${expires}. Usalo como <code>Bearer</code> en ${appName}.</p>` +
+                `<textarea readonly class="w-full h-32 p-3 text-xs font-mono rounded-xl border border-slate-200" onclick="this.select()">${data.access_token}</textarea>`,
+            confirmButtonText: 'Copiar y cerrar',
+            confirmButtonColor: '#4f46e5',
+            background: isDark ? '#1e293b' : '#fff',
+            color: isDark ? '#f8fafc' : '#0f172a',
+            customClass: {
+                popup: 'rounded-3xl',
+                confirmButton: 'rounded-xl font-bold'
+            }
+        });
+        await navigator.clipboard.writeText(data.access_token).catch(() => {});
+        window.location.reload();
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    }
+}
@@ -15,8 +15,8 @@ function closeRoleModal() {
     document.getElementById('roleForm').reset();
 }
 
-// Crear un nuevo rol
-async function createRole(event) {
+// Crear un nuevo rol propio de la aplicación
+async function createRole(event, appID) {
     event.preventDefault();
     const btn = document.getElementById('submitRoleBtn');
     const roleNameInput = document.getElementById('roleName');
@@ -26,7 +26,7 @@ async function createRole(event) {
     btn.innerText = 'Creando...';
 
     try {
-        const response = await fetch('/admin/roles', {
+        const response = await fetch(`/admin/apps/${appID}/roles`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({ name: roleName })
@@ -51,8 +51,8 @@ async function createRole(event) {
     }
 }
 
-// Eliminar un rol
-async function deleteRole(roleName) {
+// Eliminar un rol propio de la aplicación
+async function deleteRole(appID, roleName) {
     const confirmed = await peakConfirm({
         title: `¿Eliminar rol "${roleName}"?`,
         text: 'Se verificará que ningún usuario tenga este rol asignado. Si alguien lo tiene, no podrá eliminarse.',
@@ -63,7 +63,7 @@ async function deleteRole(roleName) {
     if (!confirmed) return;
 
     try {
-        const response = await fetch('/admin/roles', {
+        const response = await fetch(`/admin/apps/${appID}/roles`, {
             method: 'DELETE',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({ name: roleName })
@@ -82,11 +82,68 @@ async function deleteRole(roleName) {
     }
 }
 
+// Editar los roles que hereda un rol propio de la aplicación.
+async function editRoleParents(appID, roleName, currentParents) {
+    const current = new Set(currentParents || []);
+    const candidates = [...document.querySelectorAll('[data-role-name]')]
+        .map((el) => el.dataset.roleName)
+        .filter((name) => name !== roleName && name !== 'ROOT');
+
+    if (candidates.length === 0) {
+        peakAlert('Sin roles', 'No hay otros roles que se puedan heredar en esta aplicación', 'info');
+        return;
+    }
+
+    const fields = candidates.map((name) => `<label class="flex items-center gap-3 mb-2 text-left">
+                <input type="checkbox" class="role-parent w-4 h-4" value="${name}" ${current.has(name) ? 'checked' : ''}>
+                <span class="text-sm font-bold">${name}</span>
+            </label>`).join('');
+
+    const isDark = document.documentElement.classList.contains('dark');
+    const result = await Swal.fire({
+        title: `Herencia de ${roleName}`,
+        html: `<p class="text-sm text-slate-500 mb-4">Quien tenga ${roleName} recibe también los roles marcados.</p>${fields}`,
+        showCancelButton: true,
+        confirmButtonText: 'Guardar',
+        cancelButtonText: 'Cancelar',
+        confirmButtonColor: '#0284c7',
+        cancelButtonColor: '#64748b',
+        background: isDark ? '#1e293b' : '#fff',
+        color: isDark ? '#f8fafc' : '#0f172a',
+        reverseButtons: true,
+        customClass: {
+            popup: 'rounded-3xl',
+            confirmButton: 'rounded-xl font-bold',
+            cancelButton: 'rounded-xl font-bold'
+        },
+        preConfirm: () => [...document.querySelectorAll('.role-parent:checked')].map((el) => el.value)
+    });
+    if (!result.isConfirmed) return;
+
+    try {
+        const response = await fetch(`/admin/apps/${appID}/roles/${encodeURIComponent(roleName)}/parents`, {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ parents: result.value })
+        });
+        const data = await response.json();
+
+        if (response.ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('No se pudo guardar', data.error, 'warning');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión con el servidor', 'error');
+    }
+}
+
 // Revocar el acceso de un usuario a la aplicación.
 async function revokeAccess(appID, userID) {
     const confirmed = await peakConfirm({
         title: '¿Revocar acceso?',
-        text: 'El usuario perderá todos sus roles en esta aplicación.',
+        text: 'El usuario perderá los roles asignados en esta aplicación (los que recibe por grupos se mantienen).',
         confirmText: 'Sí, revocar',
         type: 'danger'
     });
@@ -97,11 +154,16 @@ async function revokeAccess(appID, userID) {
                 method: 'DELETE'
             });
 
-            if (response.ok) {
+            const data = await response.json();
+            if (response.ok && data.groups) {
+                // Los roles concedidos por grupos no se revocan desde la app
+                await peakAlert('Acceso por grupos', data.message, 'warning');
+                window.location.reload();
+            } else if (response.ok) {
                 showToast('Acceso revocado');
                 setTimeout(() => window.location.reload(), 800);
             } else {
-                peakAlert('Error', 'No se pudo revocar el acceso', 'error');
+                peakAlert('Error', data.error || 'No se pudo revocar el acceso', 'error');
             }
         } catch (err) {
             peakAlert('Error', 'Error de conexión', 'error');
@@ -123,6 +185,10 @@ async function assignUser(event, appID) {
         const body = new URLSearchParams();
         body.append('email', email);
         body.append('role', role);
+        // datetime-local no lleva zona horaria: se envía en RFC 3339 con la del navegador
+        for (const field of ['starts_at', 'expires_at']) {
+            if (form[field].value) body.append(field, new Date(form[field].value).toISOString());
+        }
 
         const response = await fetch(`/admin/apps/${appID}/users`, {
             method: 'POST',
@@ -131,7 +197,8 @@ async function assignUser(event, appID) {
         });
 
         if (response.ok) {
-            await peakAlert('Éxito', 'Usuario y Rol vinculados correctamente a esta aplicación.', 'success');
+            const data = await response.json();
+            await peakAlert(data.invited ? 'Invitación enviada' : 'Éxito', data.message, 'success');
             window.location.reload();
         } else {
             let msg = 'Error al vincular usuario';
@@ -150,6 +217,140 @@ async function assignUser(event, appID) {
     }
 }
 
+// Reenviar una invitación pendiente con un link nuevo
+async function resendInvitation(appID, invitationID) {
+    try {
+        const response = await fetch(`/admin/apps/${appID}/invitations/${invitationID}/resend`, {
+            method: 'POST'
+        });
+        const data = await response.json();
+
+        if (response.ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('Error', data.error || 'No se pudo reenviar la invitación', 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    }
+}
+
+// Revocar una invitación pendiente
+async function revokeInvitation(appID, invitationID) {
+    const confirmed = await peakConfirm({
+        title: '¿Revocar invitación?',
+        text: 'El link enviado dejará de funcionar.',
+        confirmText: 'Sí, revocar',
+        type: 'danger'
+    });
+    if (!confirmed) return;
+
+    try {
+        const response = await fetch(`/admin/apps/${appID}/invitations/${invitationID}`, {
+            method: 'DELETE'
+        });
+        const data = await response.json();
+
+        if (response.ok) {
+            showToast(data.message);
+            setTimeout(() => window.location.reload(), 800);
+        } else {
+            peakAlert('Error', data.error || 'No se pudo revocar la invitación', 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    }
+}
+
+// Editar los atributos personalizados del usuario según la ATTRIBUTE_SCHEMA de la app
+async function editUserAttributes(appID, userID) {
+    let view;
+    try {
+        const response = await fetch(`/admin/apps/${appID}/users/${userID}/attributes`);
+        view = await response.json();
+        if (!response.ok) {
+            peakAlert('Error', view.error || 'No se pudieron cargar los atributos', 'error');
+            return;
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+        return;
+    }
+
+    const escape = (value) => String(value ?? '').replace(/[&<>"']/g, (c) => ({ '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' }[c]));
+    const inputClass = 'w-full px-3 py-2 bg-slate-50 dark:bg-slate-800 border border-slate-200 dark:border-slate-700 rounded-xl text-sm outline-none';
+    const fields = view.schema.map((attr) => {
+        const value = view.values[attr.key];
+        const id = `attr_${attr.key}`;
+        let input;
+        if (attr.type === 'boolean') {
+            input = `<input type="checkbox" id="${id}" ${value === true ? 'checked' : ''}>`;
+        } else if (attr.type === 'string' && attr.enum && attr.enum.length) {
+            const options = attr.enum.map((opt) => `<option value="${escape(opt)}" ${opt === value ? 'selected' : ''}>${escape(opt)}</option>`).join('');
+            input = `<select id="${id}" class="${inputClass}"><option value=""></option>${options}</select>`;
+        } else {
+            const type = attr.type === 'number' ? 'number' : attr.type === 'date' ? 'date' : 'text';
+            input = `<input type="${type}" id="${id}" value="${escape(value)}" class="${inputClass}" ${attr.max_length ? `maxlength="${attr.max_length}"` : ''}>`;
+        }
+        return `<label class="block text-left mb-3">
+                    <span class="block text-xs font-bold text-slate-500 mb-1">${escape(attr.label || attr.key)}${attr.required ? ' *' : ''}</span>
+                    ${input}
+                </label>`;
+    }).join('');
+
+    const isDark = document.documentElement.classList.contains('dark');
+    const result = await Swal.fire({
+        title: 'Atributos del usuario',
+        html: fields,
+        showCancelButton: true,
+        confirmButtonText: 'Guardar',
+        cancelButtonText: 'Cancelar',
+        confirmButtonColor: '#0284c7',
+        cancelButtonColor: '#64748b',
+        background: isDark ? '#1e293b' : '#fff',
+        color: isDark ? '#f8fafc' : '#0f172a',
+        reverseButtons: true,
+        customClass: {
+            popup: 'rounded-3xl',
+            confirmButton: 'rounded-xl font-bold',
+            cancelButton: 'rounded-xl font-bold'
+        },
+        preConfirm: () => {
+            const changes = {};
+            view.schema.forEach((attr) => {
+                const el = document.getElementById(`attr_${attr.key}`);
+                if (attr.type === 'boolean') {
+                    changes[attr.key] = el.checked;
+                } else if (el.value === '') {
+                    changes[attr.key] = null;
+                } else {
+                    changes[attr.key] = attr.type === 'number' ? Number(el.value) : el.value;
+                }
+            });
+            return changes;
+        }
+    });
+    if (!result.isConfirmed) return;
+
+    try {
+        const response = await fetch(`/admin/apps/${appID}/users/${userID}/attributes`, {
+            method: 'PATCH',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(result.value)
+        });
+        const data = await response.json();
+
+        if (response.ok) {
+            showToast(data.message);
+        } else {
+            peakAlert('Error', data.error || 'No se pudieron guardar los atributos', 'error');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    }
+}
+
 // Desbloquear usuario (resetear intentos fallidos)
 async function unlockUser(appID, userID) {
     try {
@@ -168,6 +369,78 @@ async function unlockUser(appID, userID) {
     }
 }
 
+// Importar usuarios desde un archivo CSV/JSON (o sólo validarlo en modo dry-run)
+async function importUsers(event, appID) {
+    event.preventDefault();
+    const form = event.target;
+    const btn = form.querySelector('button[type="submit"]');
+
+    btn.disabled = true;
+    btn.innerText = 'Procesando...';
+
+    try {
+        const response = await fetch(`/admin/apps/${appID}/users/import`, {
+            method: 'POST',
+            body: new FormData(form)
+        });
+        const data = await response.json();
+
+        if (!response.ok) {
+            if (data.report) renderImportReport(data.report);
+            peakAlert('Error', data.error || 'No se pudo importar el archivo', 'error');
+            return;
+        }
+
+        renderImportReport(data);
+        if (data.applied) {
+            showToast(`Importación completa: ${data.created} nuevos, ${data.invited} invitados, ${data.linked} vinculados`);
+            setTimeout(() => window.location.reload(), 2000);
+        } else if (data.failed > 0) {
+            showToast(`${data.failed} fila(s) con errores: no se importó nada`, 'error');
+        } else {
+            showToast('Archivo válido. Desmarcá "Sólo validar" para importarlo');
+        }
+    } catch (err) {
+        peakAlert('Error', 'Error de conexión', 'error');
+    } finally {
+        btn.disabled = false;
+        btn.innerText = 'Procesar archivo';
+    }
+}
+
+// Mostrar el resumen y las filas con errores del reporte de importación
+function renderImportReport(report) {
+    const container = document.getElementById('importReport');
+    const labels = { created: 'Nuevo', invited: 'Invitado', linked: 'Vinculado', unchanged: 'Sin cambios', failed: 'Error' };
+    const colors = { created: 'text-emerald-600', invited: 'text-amber-600', linked: 'text-brand-600', unchanged: 'text-slate-400', failed: 'text-rose-600' };
+
+    container.replaceChildren();
+    container.classList.remove('hidden');
+
+    const summary = document.createElement('p');
+    summary.className = 'text-xs font-bold text-slate-500 dark:text-slate-400 mb-3';
+    summary.textContent = `${report.total} fila(s) · ${report.created} nuevos · ${report.invited} invitados · ${report.linked} vinculados · ` +
+        `${report.unchanged} sin cambios · ${report.failed} con errores · ${report.activations} links de activación` +
+        (report.dry_run ? ' (dry-run)' : '');
+    container.appendChild(summary);
+
+    const list = document.createElement('ul');
+    list.className = 'space-y-1 max-h-64 overflow-y-auto pr-2 custom-scrollbar text-xs';
+    (report.rows || []).forEach(row => {
+        const item = document.createElement('li');
+        item.className = 'flex justify-between gap-2';
+        const email = document.createElement('span');
+        email.className = 'truncate text-slate-600 dark:text-slate-300';
+        email.textContent = `${row.line}. ${row.email || '(sin email)'}`;
+        const status = document.createElement('span');
+        status.className = `font-bold shrink-0 ${colors[row.status] || ''}`;
+        status.textContent = row.errors ? row.errors.join('; ') : labels[row.status];
+        item.append(email, status);
+        list.appendChild(item);
+    });
+    container.appendChild(list);
+}
+
 // Event listeners para los botones del modal de roles
 document.addEventListener('DOMContentLoaded', () => {
     const openRoleBtn = document.getElementById('openRoleModalBtn');
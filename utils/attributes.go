@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Attribute types that ATTRIBUTE_SCHEMA can declare.
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeDate    = "date" // YYYY-MM-DD
+)
+
+// Who can change an attribute value.
+const (
+	AttributeEditableByUser  = "user"  // El usuario desde /api/v1/me (y el admin)
+	AttributeEditableByAdmin = "admin" // Sólo el admin desde la consola
+)
+
+const (
+	maxSchemaAttributes      = 50
+	attributeStringMaxLength = 255
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// AttributeDefinition describes one custom user attribute of an application.
+type AttributeDefinition struct {
+	Key        string   `json:"key"`
+	Label      string   `json:"label"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required"`
+	EditableBy string   `json:"editable_by"` // "user" o "admin" (por defecto)
+	InToken    bool     `json:"in_token"`    // Se proyecta en el claim "attrs" del JWT
+	MaxLength  int      `json:"max_length"`  // Sólo string; 0 = 255
+	Enum       []string `json:"enum"`        // Sólo string; vacío = cualquier valor
+}
+
+// AttributeSchema is the ATTRIBUTE_SCHEMA rule. Without the rule the app has no custom attributes.
+type AttributeSchema struct {
+	Attributes []AttributeDefinition `json:"attributes"`
+}
+
+// ParseAttributeSchema extracts the attribute schema and rejects inconsistent definitions
+func ParseAttributeSchema(raw []byte) (*AttributeSchema, error) {
+	var s AttributeSchema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: %w", err)
+	}
+	if len(s.Attributes) > maxSchemaAttributes {
+		return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: no se admiten más de %d atributos", maxSchemaAttributes)
+	}
+
+	seen := map[string]bool{}
+	for i := range s.Attributes {
+		def := &s.Attributes[i]
+		if !attributeKeyPattern.MatchString(def.Key) {
+			return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: la clave %q debe usar minúsculas, números y guión bajo", def.Key)
+		}
+		if seen[def.Key] {
+			return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: la clave %q está repetida", def.Key)
+		}
+		seen[def.Key] = true
+
+		switch def.Type {
+		case AttributeTypeString, AttributeTypeNumber, AttributeTypeBoolean, AttributeTypeDate:
+		default:
+			return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: tipo desconocido %q en %s", def.Type, def.Key)
+		}
+
+		if def.EditableBy == "" {
+			def.EditableBy = AttributeEditableByAdmin
+		}
+		if def.EditableBy != AttributeEditableByUser && def.EditableBy != AttributeEditableByAdmin {
+			return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: editable_by de %s debe ser user o admin", def.Key)
+		}
+
+		if def.Type != AttributeTypeString && (def.MaxLength != 0 || len(def.Enum) > 0) {
+			return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: max_length y enum sólo aplican a atributos string (%s)", def.Key)
+		}
+		if def.MaxLength < 0 || def.MaxLength > attributeStringMaxLength {
+			return nil, fmt.Errorf("invalid ATTRIBUTE_SCHEMA rule: max_length de %s debe estar entre 0 y %d", def.Key, attributeStringMaxLength)
+		}
+	}
+	return &s, nil
+}
+
+// Find returns the definition of an attribute key.
+func (s AttributeSchema) Find(key string) (AttributeDefinition, bool) {
+	for _, def := range s.Attributes {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return AttributeDefinition{}, false
+}
+
+// UserEditableKeys returns the attributes the end user can change.
+func (s AttributeSchema) UserEditableKeys() []string {
+	keys := []string{}
+	for _, def := range s.Attributes {
+		if def.EditableBy == AttributeEditableByUser {
+			keys = append(keys, def.Key)
+		}
+	}
+	return keys
+}
+
+// Visible filters the stored values down to the attributes declared in the schema
+// (values of attributes removed from the schema are kept in storage but not exposed).
+func (s AttributeSchema) Visible(values map[string]interface{}) map[string]interface{} {
+	visible := map[string]interface{}{}
+	for _, def := range s.Attributes {
+		if v, ok := values[def.Key]; ok {
+			visible[def.Key] = v
+		}
+	}
+	return visible
+}
+
+// TokenClaims returns the values projected into the JWT, or nil if there are none.
+func (s AttributeSchema) TokenClaims(values map[string]interface{}) map[string]interface{} {
+	var claims map[string]interface{}
+	for _, def := range s.Attributes {
+		v, ok := values[def.Key]
+		if !def.InToken || !ok {
+			continue
+		}
+		if claims == nil {
+			claims = map[string]interface{}{}
+		}
+		claims[def.Key] = v
+	}
+	return claims
+}
+
+// ApplyChanges validates a PATCH over the stored values and returns the result.
+// A nil value removes the attribute. Without byAdmin only user-editable attributes can change
+// and only those are checked as required.
+func (s AttributeSchema) ApplyChanges(current, changes map[string]interface{}, byAdmin bool) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(current)+len(changes))
+	for k, v := range current {
+		result[k] = v
+	}
+
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		def, ok := s.Find(key)
+		if !ok {
+			return nil, fmt.Errorf("el atributo %s no existe en esta aplicación", key)
+		}
+		if !byAdmin && def.EditableBy != AttributeEditableByUser {
+			return nil, fmt.Errorf("el atributo %s no es editable en esta aplicación", key)
+		}
+		if changes[key] == nil {
+			delete(result, key)
+			continue
+		}
+		value, err := def.normalize(changes[key])
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+
+	for _, def := range s.Attributes {
+		if !def.Required || (!byAdmin && def.EditableBy != AttributeEditableByUser) {
+			continue
+		}
+		if _, ok := result[def.Key]; !ok {
+			return nil, fmt.Errorf("el atributo %s es obligatorio", def.Key)
+		}
+	}
+	return result, nil
+}
+
+// normalize checks the value against the definition and returns it in its canonical form.
+func (d AttributeDefinition) normalize(value interface{}) (interface{}, error) {
+	switch d.Type {
+	case AttributeTypeString:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("el atributo %s debe ser un texto", d.Key)
+		}
+		str = strings.TrimSpace(str)
+		maxLength := d.MaxLength
+		if maxLength == 0 {
+			maxLength = attributeStringMaxLength
+		}
+		if len([]rune(str)) > maxLength {
+			return nil, fmt.Errorf("el atributo %s no puede superar los %d caracteres", d.Key, maxLength)
+		}
+		if len(d.Enum) > 0 && !slices.Contains(d.Enum, str) {
+			return nil, fmt.Errorf("el atributo %s debe ser uno de: %s", d.Key, strings.Join(d.Enum, ", "))
+		}
+		return str, nil
+	case AttributeTypeNumber:
+		num, ok := value.(float64)
+		if !ok || math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, fmt.Errorf("el atributo %s debe ser un número", d.Key)
+		}
+		return num, nil
+	case AttributeTypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("el atributo %s debe ser true o false", d.Key)
+		}
+		return b, nil
+	case AttributeTypeDate:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("el atributo %s debe tener el formato AAAA-MM-DD", d.Key)
+		}
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return nil, fmt.Errorf("el atributo %s debe tener el formato AAAA-MM-DD", d.Key)
+		}
+		return str, nil
+	}
+	return nil, fmt.Errorf("el atributo %s tiene un tipo desconocido", d.Key)
+}
@@ -1,3 +1,6 @@
 package utils
 
 const AppID_PEAK_AUTH = "peak-auth-raiz"
+
+// RoleImpersonator habilita, en la app raíz, a suplantar usuarios desde la consola (además de ROOT).
+const RoleImpersonator = "IMPERSONATOR"
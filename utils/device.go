@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+)
+
+// IPPrefix reduce la IP a su red (/24 en IPv4, /48 en IPv6) para que un cambio
+// de IP dentro del mismo proveedor no cuente como dispositivo nuevo.
+func IPPrefix(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+// DeviceFingerprint calcula la huella de un login a partir del user agent y el prefijo de IP.
+func DeviceFingerprint(userAgent, ip string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(userAgent) + "|" + IPPrefix(ip)))
+	return hex.EncodeToString(sum[:])
+}
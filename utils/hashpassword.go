@@ -21,3 +21,9 @@ func CheckTokenSHA256(plainToken string, hashFromDB []byte) bool {
 	hashedToken := sha256.Sum256([]byte(plainToken))
 	return hmac.Equal(hashedToken[:], hashFromDB)
 }
+
+// IsPasswordHash indica si el valor es un hash bcrypt válido (p.ej. al importar usuarios).
+func IsPasswordHash(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
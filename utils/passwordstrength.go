@@ -0,0 +1,579 @@
+package utils
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// PasswordStrength es el resultado del estimador de fortaleza (estilo zxcvbn).
+// Score va de 0 (muy débil) a 4 (muy fuerte) según los intentos estimados para adivinarla.
+type PasswordStrength struct {
+	Score        int      `json:"score"`
+	GuessesLog10 float64  `json:"guesses_log10"`
+	Warning      string   `json:"warning,omitempty"`
+	Suggestions  []string `json:"suggestions"`
+}
+
+// Tipos de patrón detectados en una contraseña.
+const (
+	patternDictionary = "dictionary"
+	patternUserInput  = "user_input"
+	patternSpatial    = "spatial"
+	patternRepeat     = "repeat"
+	patternSequence   = "sequence"
+	patternYear       = "year"
+)
+
+type strengthMatch struct {
+	pattern      string
+	i, j         int // Posiciones (inclusive) dentro de la contraseña
+	guessesLog10 float64
+	rank         int
+	reversed     bool
+	l33t         bool
+}
+
+// Límite de caracteres analizados (los detectores son cuadráticos o peores). Lo que excede
+// no suma: si sumara como fuerza bruta, rellenar una contraseña débil la haría "fuerte".
+const maxStrengthAnalysisLength = 64
+
+// commonPasswords está ordenada por frecuencia: la posición es el rank del diccionario.
+var commonPasswords = []string{
+	"123456", "password", "123456789", "12345678", "12345", "qwerty", "1234567", "111111",
+	"123123", "abc123", "1234567890", "password1", "1234", "iloveyou", "000000", "qwerty123",
+	"admin", "welcome", "monkey", "dragon", "letmein", "football", "baseball", "master",
+	"sunshine", "princess", "login", "passw0rd", "starwars", "shadow", "superman", "trustno1",
+	"michael", "jennifer", "hello", "freedom", "whatever", "qazwsx", "batman", "soccer",
+	"charlie", "secret", "access", "flower", "hunter", "killer", "pepper", "ninja",
+	"mustang", "jordan", "harley", "ranger", "cheese", "summer", "winter", "spring",
+	"autumn", "computer", "internet", "samsung", "google", "facebook", "linkedin", "peakauth",
+	"contraseña", "contrasena", "clave", "usuario", "hola", "holamundo", "teamo", "futbol",
+	"boca", "river", "argentina", "mexico", "espana", "colombia", "chile", "peru",
+	"amor", "familia", "bienvenido", "secreto", "acceso", "sistema", "administrador", "prueba",
+	"test", "testing", "demo", "guest", "invitado", "root", "toor", "changeme",
+	"default", "love", "lovely", "angel", "baby", "money", "family", "friends",
+	"forever", "qwertyuiop", "asdfgh", "zxcvbn", "abcdef", "abcd1234", "pass", "pass123",
+	"company", "empresa", "office", "oficina", "server", "database", "mysql", "postgres",
+	"123qwe", "1q2w3e4r", "1qaz2wsx", "zaq12wsx", "qwe123", "123321", "654321", "666666",
+	"121212", "112233", "7777777", "888888", "999999", "987654321", "password123", "admin123",
+	"root123", "welcome1", "welcome123", "letmein123", "qwerty1", "qwerty12", "asdf1234", "asdfghjkl",
+	"zxcvbnm", "1q2w3e", "q1w2e3r4", "aa123456", "abc12345", "a123456", "123abc", "12341234",
+	"password12", "password2", "admin1", "administrator", "adminadmin", "root1234", "test123", "hunter2",
+	"correcthorsebatterystaple", "troubador", "iloveu", "iloveyou1", "loveyou", "michelle", "jessica", "ashley",
+	"daniel", "thomas", "andrew", "joshua", "matthew", "robert", "anthony", "hannah",
+	"buster", "tigger", "hockey", "george", "pokemon", "naruto", "chocolate", "butterfly",
+	"purple", "orange", "yellow", "banana", "cookie", "maggie", "ginger", "snoopy",
+	"liverpool", "chelsea", "arsenal", "barcelona", "realmadrid", "madrid", "juventus", "mercedes",
+	"ferrari", "corvette", "yankees", "dallas", "london", "paris", "berlin", "oracle",
+	"passwort", "motdepasse", "senha", "mudar123", "teamo123", "tequiero", "mariposa", "princesa",
+	"estrella", "corazon", "angelito", "dios", "jesus", "jesucristo", "maria", "jose",
+	"juan", "carlos", "alejandro", "sebastian", "valentina", "camila", "sofia", "martina",
+	"lucas", "mateo", "santiago", "diego", "gabriel", "fernando", "roberto", "ricardo",
+	"eduardo", "daniela", "andrea", "natalia", "monica", "patricia", "gatito", "perrito",
+	"cambiame", "cambiar", "nuevaclave", "miclave", "secreto123", "usuario1", "prueba123", "temporal",
+	"temp123", "inicio", "inicio123", "bienvenida", "verano", "invierno", "primavera", "otono",
+	"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto",
+	"septiembre", "octubre", "noviembre", "diciembre", "january", "monday", "friday", "sunday",
+	"abcdefg", "abcdefgh", "11111111", "000000000", "123456a", "a12345", "zaq1zaq1", "sqlserver",
+}
+
+var commonPasswordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, w := range commonPasswords {
+		if _, exists := ranks[w]; !exists {
+			ranks[w] = i + 1
+		}
+	}
+	return ranks
+}()
+
+// Filas del teclado QWERTY usadas para detectar patrones espaciales.
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+var keyboardPositions = func() map[rune][2]int {
+	positions := make(map[rune][2]int)
+	for r, row := range keyboardRows {
+		for c, ch := range row {
+			positions[ch] = [2]int{r, c}
+		}
+	}
+	return positions
+}()
+
+var l33tTable = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i',
+	'!': 'i', '|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+var yearRegex = regexp.MustCompile(`19\d\d|20\d\d`)
+var inputSplitRegex = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// EstimatePasswordStrength estima la fortaleza de una contraseña buscando palabras de
+// diccionario, patrones de teclado, repeticiones, secuencias y años. userInputs agrega
+// palabras propias del contexto (email, nombre de la app, etc.) al diccionario.
+func EstimatePasswordStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return PasswordStrength{
+			Score:       0,
+			Warning:     "La contraseña está vacía",
+			Suggestions: []string{"Usá varias palabras poco comunes"},
+		}
+	}
+
+	analyzed := runes
+	if len(analyzed) > maxStrengthAnalysisLength {
+		analyzed = analyzed[:maxStrengthAnalysisLength]
+	}
+
+	matches := findStrengthMatches(analyzed, buildUserDictionary(userInputs))
+	guessesLog10, sequence := minimumGuesses(analyzed, matches)
+
+	result := PasswordStrength{
+		Score:        scoreFromGuesses(guessesLog10),
+		GuessesLog10: math.Round(guessesLog10*100) / 100,
+	}
+	result.Warning, result.Suggestions = strengthFeedback(result.Score, sequence, analyzed)
+	return result
+}
+
+// buildUserDictionary arma el diccionario de entradas del usuario, incluyendo cada
+// fragmento alfanumérico (p.ej. "juan.perez@acme.com" -> "juan", "perez", "acme").
+func buildUserDictionary(inputs []string) map[string]int {
+	dict := make(map[string]int)
+	rank := 1
+	add := func(w string) {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if len([]rune(w)) < 3 {
+			return
+		}
+		if _, exists := dict[w]; !exists {
+			dict[w] = rank
+			rank++
+		}
+	}
+	for _, input := range inputs {
+		add(input)
+		if at := strings.Index(input, "@"); at > 0 {
+			add(input[:at])
+		}
+		for _, part := range inputSplitRegex.Split(input, -1) {
+			add(part)
+		}
+	}
+	return dict
+}
+
+func findStrengthMatches(runes []rune, userDict map[string]int) []strengthMatch {
+	var matches []strengthMatch
+	matches = append(matches, dictionaryMatches(runes, userDict)...)
+	matches = append(matches, spatialMatches(runes)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+	return matches
+}
+
+func dictionaryMatches(runes []rune, userDict map[string]int) []strengthMatch {
+	var matches []strengthMatch
+	lower := []rune(strings.ToLower(string(runes)))
+	unl33t := make([]rune, len(lower))
+	for i, r := range lower {
+		if sub, ok := l33tTable[r]; ok {
+			unl33t[i] = sub
+		} else {
+			unl33t[i] = r
+		}
+	}
+	n := len(runes)
+
+	lookup := func(word string) (int, string, bool) {
+		if rank, ok := userDict[word]; ok {
+			return rank, patternUserInput, true
+		}
+		if rank, ok := commonPasswordRanks[word]; ok {
+			return rank, patternDictionary, true
+		}
+		return 0, "", false
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			candidates := []struct {
+				word     string
+				l33t     bool
+				reversed bool
+			}{
+				{string(lower[i : j+1]), false, false},
+				{reverseString(string(lower[i : j+1])), false, true},
+			}
+			if plain := string(unl33t[i : j+1]); plain != string(lower[i:j+1]) {
+				candidates = append(candidates, struct {
+					word     string
+					l33t     bool
+					reversed bool
+				}{plain, true, false})
+			}
+
+			for _, cand := range candidates {
+				rank, pattern, ok := lookup(cand.word)
+				if !ok {
+					continue
+				}
+				guesses := math.Log10(float64(rank)) + uppercaseVariationsLog10(runes[i:j+1])
+				if cand.l33t {
+					guesses += math.Log10(2)
+				}
+				if cand.reversed {
+					guesses += math.Log10(2)
+				}
+				matches = append(matches, strengthMatch{
+					pattern:      pattern,
+					i:            i,
+					j:            j,
+					guessesLog10: guesses,
+					rank:         rank,
+					reversed:     cand.reversed,
+					l33t:         cand.l33t,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// uppercaseVariationsLog10 penaliza poco las mayúsculas predecibles (inicial o todo en mayúsculas).
+func uppercaseVariationsLog10(word []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 0
+	}
+	if lower == 0 || (upper == 1 && unicode.IsUpper(word[0])) || (upper == 1 && unicode.IsUpper(word[len(word)-1])) {
+		return math.Log10(2)
+	}
+	return float64(upper+lower) * math.Log10(2) / 2
+}
+
+func spatialMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	lower := []rune(strings.ToLower(string(runes)))
+	n := len(lower)
+
+	adjacent := func(a, b rune) (bool, [2]int) {
+		pa, okA := keyboardPositions[a]
+		pb, okB := keyboardPositions[b]
+		if !okA || !okB {
+			return false, [2]int{}
+		}
+		dr, dc := pb[0]-pa[0], pb[1]-pa[1]
+		if dr < -1 || dr > 1 || dc < -1 || dc > 1 || (dr == 0 && dc == 0) {
+			return false, [2]int{}
+		}
+		return true, [2]int{dr, dc}
+	}
+
+	i := 0
+	for i < n-2 {
+		j := i
+		turns := 0
+		var lastDir [2]int
+		for j+1 < n {
+			ok, dir := adjacent(lower[j], lower[j+1])
+			if !ok {
+				break
+			}
+			if j == i || dir != lastDir {
+				turns++
+			}
+			lastDir = dir
+			j++
+		}
+		if j-i+1 >= 3 {
+			length := float64(j - i + 1)
+			// Teclas de inicio * grado medio de adyacencia por cada giro * largo
+			guesses := math.Log10(47) + float64(turns)*math.Log10(4) + math.Log10(length)
+			matches = append(matches, strengthMatch{pattern: patternSpatial, i: i, j: j, guessesLog10: guesses})
+			i = j + 1
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+func repeatMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	n := len(runes)
+	for i := 0; i < n; i++ {
+		for baseLen := 1; baseLen <= (n-i)/2; baseLen++ {
+			base := runes[i : i+baseLen]
+			reps := 1
+			for k := i + baseLen; k+baseLen <= n && string(runes[k:k+baseLen]) == string(base); k += baseLen {
+				reps++
+			}
+			if reps < 2 || (baseLen == 1 && reps < 3) {
+				continue
+			}
+			j := i + baseLen*reps - 1
+			baseGuesses := float64(baseLen) * math.Log10(float64(bruteforceCardinality(base)))
+			if baseLen > 3 {
+				baseGuesses = math.Min(baseGuesses, 4)
+			}
+			guesses := baseGuesses + math.Log10(float64(reps))
+			matches = append(matches, strengthMatch{pattern: patternRepeat, i: i, j: j, guessesLog10: guesses})
+		}
+	}
+	return matches
+}
+
+func sequenceMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	lower := []rune(strings.ToLower(string(runes)))
+	n := len(lower)
+
+	i := 0
+	for i < n-2 {
+		delta := lower[i+1] - lower[i]
+		if delta != 1 && delta != -1 {
+			i++
+			continue
+		}
+		j := i + 1
+		for j+1 < n && lower[j+1]-lower[j] == delta {
+			j++
+		}
+		if j-i+1 >= 3 {
+			start := lower[i]
+			var base float64
+			switch {
+			case start == 'a' || start == 'z' || start == '0' || start == '1' || start == '9':
+				base = 4
+			case unicode.IsDigit(start):
+				base = 10
+			default:
+				base = 26
+			}
+			guesses := math.Log10(base) + math.Log10(float64(j-i+1))
+			if delta < 0 {
+				guesses += math.Log10(2)
+			}
+			matches = append(matches, strengthMatch{pattern: patternSequence, i: i, j: j, guessesLog10: guesses})
+			i = j + 1
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+func yearMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	s := string(runes)
+	for _, loc := range yearRegex.FindAllStringIndex(s, -1) {
+		i := len([]rune(s[:loc[0]]))
+		j := i + 3
+		matches = append(matches, strengthMatch{pattern: patternYear, i: i, j: j, guessesLog10: math.Log10(50)})
+	}
+	return matches
+}
+
+// minimumGuesses busca la descomposición de la contraseña en patrones que requiere
+// menos intentos; los caracteres sin patrón se estiman por fuerza bruta.
+func minimumGuesses(runes []rune, matches []strengthMatch) (float64, []strengthMatch) {
+	n := len(runes)
+	charGuesses := math.Log10(float64(bruteforceCardinality(runes)))
+
+	type step struct {
+		cost     float64
+		segments int
+		prev     int
+		match    *strengthMatch
+	}
+	best := make([]step, n+1)
+	for k := 1; k <= n; k++ {
+		best[k] = step{cost: math.Inf(1)}
+	}
+
+	byEnd := make(map[int][]int)
+	for idx, m := range matches {
+		byEnd[m.j] = append(byEnd[m.j], idx)
+	}
+
+	for k := 1; k <= n; k++ {
+		// Carácter por fuerza bruta; se agrupa con el anterior si también lo era
+		prev := best[k-1]
+		segments := prev.segments
+		if prev.match != nil || k == 1 {
+			segments++
+		}
+		if c := prev.cost + charGuesses; c < best[k].cost {
+			best[k] = step{cost: c, segments: segments, prev: k - 1}
+		}
+
+		for _, idx := range byEnd[k-1] {
+			m := &matches[idx]
+			from := best[m.i]
+			c := from.cost + math.Max(m.guessesLog10, 0)
+			if c < best[k].cost {
+				best[k] = step{cost: c, segments: from.segments + 1, prev: m.i, match: m}
+			}
+		}
+	}
+
+	var sequence []strengthMatch
+	for k := n; k > 0; k = best[k].prev {
+		if best[k].match != nil {
+			sequence = append([]strengthMatch{*best[k].match}, sequence...)
+		}
+	}
+
+	// Un atacante también debe adivinar cómo se combinan los segmentos
+	total := best[n].cost + log10Factorial(best[n].segments)
+	return total, sequence
+}
+
+func bruteforceCardinality(runes []rune) int {
+	var lower, upper, digits, symbols, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digits = true
+		case r < 128:
+			symbols = true
+		default:
+			other = true
+		}
+	}
+	cardinality := 0
+	if lower {
+		cardinality += 26
+	}
+	if upper {
+		cardinality += 26
+	}
+	if digits {
+		cardinality += 10
+	}
+	if symbols {
+		cardinality += 33
+	}
+	if other {
+		cardinality += 100
+	}
+	if cardinality == 0 {
+		cardinality = 10
+	}
+	return cardinality
+}
+
+func log10Factorial(n int) float64 {
+	total := 0.0
+	for k := 2; k <= n; k++ {
+		total += math.Log10(float64(k))
+	}
+	return total
+}
+
+func scoreFromGuesses(guessesLog10 float64) int {
+	switch {
+	case guessesLog10 < 3:
+		return 0
+	case guessesLog10 < 6:
+		return 1
+	case guessesLog10 < 8:
+		return 2
+	case guessesLog10 < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func strengthFeedback(score int, sequence []strengthMatch, password []rune) (string, []string) {
+	if score >= 3 {
+		return "", []string{}
+	}
+
+	suggestions := []string{"Agregá una o dos palabras más; las palabras poco comunes son mejores"}
+	if len(password) < 12 {
+		suggestions = append(suggestions, "Usá una contraseña más larga, por ejemplo una frase")
+	}
+
+	// El patrón más largo es el que más explica la debilidad
+	var longest *strengthMatch
+	for idx := range sequence {
+		if longest == nil || sequence[idx].j-sequence[idx].i > longest.j-longest.i {
+			longest = &sequence[idx]
+		}
+	}
+	if longest == nil {
+		return "", suggestions
+	}
+
+	var warning string
+	switch longest.pattern {
+	case patternDictionary:
+		if longest.rank <= 20 && !longest.l33t && !longest.reversed {
+			warning = "Es una de las contraseñas más usadas"
+		} else {
+			warning = "Es similar a una contraseña muy común"
+		}
+		if longest.l33t {
+			suggestions = append(suggestions, "Reemplazos predecibles como '@' por 'a' no ayudan mucho")
+		}
+		if longest.reversed {
+			suggestions = append(suggestions, "Las palabras al revés no son mucho más difíciles de adivinar")
+		}
+		// Sugerir mayúsculas sólo si la contraseña no tiene ninguna; si la palabra ya
+		// empieza con mayúscula, avisar que eso solo no alcanza
+		word := password[longest.i : longest.j+1]
+		switch {
+		case strings.IndexFunc(string(password), unicode.IsUpper) < 0 && strings.IndexFunc(string(word), unicode.IsLetter) >= 0:
+			suggestions = append(suggestions, "Agregá alguna mayúscula, mejor en el medio de la palabra")
+		case unicode.IsUpper(word[0]) && strings.IndexFunc(string(word[1:]), unicode.IsUpper) < 0:
+			suggestions = append(suggestions, "Usar mayúscula al inicio no ayuda mucho")
+		}
+	case patternUserInput:
+		warning = "La contraseña contiene datos tuyos o de la aplicación"
+		suggestions = append(suggestions, "Evitá usar tu email, nombre o el nombre de la aplicación")
+	case patternSpatial:
+		warning = "Los patrones de teclado como 'qwerty' son fáciles de adivinar"
+		suggestions = append(suggestions, "Evitá secuencias de teclas contiguas")
+	case patternRepeat:
+		warning = "Las repeticiones como 'aaa' o 'abcabc' son fáciles de adivinar"
+		suggestions = append(suggestions, "Evitá repetir palabras y caracteres")
+	case patternSequence:
+		warning = "Las secuencias como 'abc' o '6543' son fáciles de adivinar"
+		suggestions = append(suggestions, "Evitá secuencias de letras o números")
+	case patternYear:
+		warning = "Los años son fáciles de adivinar"
+		suggestions = append(suggestions, "Evitá años y fechas asociadas a vos")
+	}
+	return warning, suggestions
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const PermissionCodeMaxLength = 100 // varchar(100) en permissions
+
+// Segmentos en minúscula separados por ":" (recurso:acción), sin espacios: el código viaja en el scope.
+var permissionCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*(:[a-z0-9*][a-z0-9_.*-]*)*$`)
+
+// NormalizePermissionCode returns the canonical form stored and compared: trimmed and lowercase.
+func NormalizePermissionCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// ValidatePermissionCode checks an already normalized permission code.
+func ValidatePermissionCode(code string) error {
+	if code == "" || len(code) > PermissionCodeMaxLength {
+		return fmt.Errorf("el permiso debe tener entre 1 y %d caracteres", PermissionCodeMaxLength)
+	}
+	if !permissionCodePattern.MatchString(code) {
+		return fmt.Errorf("el permiso %q no es válido: usa minúsculas, números, punto, guión y \":\" para separar recurso y acción (p.ej. orders:read)", code)
+	}
+	return nil
+}
+
+// PermissionMatches indicates whether a granted code covers the required one. Both are compared
+// segment by segment ("recurso:acción"); a granted segment may be a glob ("*", "read*"), so
+// "orders:*" covers "orders:read" but not "orders:lines:read".
+func PermissionMatches(granted, required string) bool {
+	grantedParts := strings.Split(granted, ":")
+	requiredParts := strings.Split(required, ":")
+	if len(grantedParts) != len(requiredParts) {
+		return false
+	}
+	for i, part := range grantedParts {
+		if ok, err := path.Match(part, requiredParts[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
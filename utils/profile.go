@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Profile fields that PROFILE_POLICY can reference.
+const (
+	ProfileFieldFirstName = "first_name"
+	ProfileFieldLastName  = "last_name"
+	ProfileFieldBirthDate = "birth_date"
+	ProfileFieldAvatarURL = "avatar_url"
+)
+
+// ProfileFields lists every profile field in display order.
+var ProfileFields = []string{ProfileFieldFirstName, ProfileFieldLastName, ProfileFieldBirthDate, ProfileFieldAvatarURL}
+
+const (
+	profileNameMaxLength   = 50  // varchar(50) en profiles
+	profileAvatarMaxLength = 255 // varchar(255) en profiles
+)
+
+// ProfilePolicy drives the validation of the self-service profile (/api/v1/me).
+// Without the rule every field is editable and optional.
+type ProfilePolicy struct {
+	Editable           []string `json:"editable"` // Empty means all fields
+	Required           []string `json:"required"`
+	NameMinLength      int      `json:"name_min_length"`
+	NameMaxLength      int      `json:"name_max_length"`
+	MinAge             int      `json:"min_age"`
+	AvatarRequireHTTPS bool     `json:"avatar_require_https"`
+	AvatarAllowedHosts []string `json:"avatar_allowed_hosts"` // Empty means any host
+}
+
+// ProfileValues is the resulting profile after applying an update, used for validation.
+type ProfileValues struct {
+	FirstName string
+	LastName  string
+	BirthDate time.Time
+	AvatarURL string
+}
+
+// ParseProfilePolicy extracts the profile policy and rejects unknown field names
+func ParseProfilePolicy(raw []byte) (*ProfilePolicy, error) {
+	var r ProfilePolicy
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("invalid PROFILE_POLICY rule: %w", err)
+	}
+	for _, f := range append(slices.Clone(r.Editable), r.Required...) {
+		if !slices.Contains(ProfileFields, f) {
+			return nil, fmt.Errorf("invalid PROFILE_POLICY rule: campo desconocido %q", f)
+		}
+	}
+	if r.NameMinLength < 0 || r.NameMaxLength < 0 || r.NameMaxLength > profileNameMaxLength {
+		return nil, fmt.Errorf("invalid PROFILE_POLICY rule: el largo de los nombres debe estar entre 0 y %d", profileNameMaxLength)
+	}
+	if r.NameMaxLength > 0 && r.NameMinLength > r.NameMaxLength {
+		return nil, fmt.Errorf("invalid PROFILE_POLICY rule: name_min_length no puede superar a name_max_length")
+	}
+	if r.MinAge < 0 {
+		return nil, fmt.Errorf("invalid PROFILE_POLICY rule: min_age no puede ser negativo")
+	}
+	return &r, nil
+}
+
+// EditableFields returns the fields the end user can change.
+func (p ProfilePolicy) EditableFields() []string {
+	if len(p.Editable) == 0 {
+		return ProfileFields
+	}
+	return p.Editable
+}
+
+// IsEditable reports whether the end user can change the field.
+func (p ProfilePolicy) IsEditable(field string) bool {
+	return slices.Contains(p.EditableFields(), field)
+}
+
+// ValidateProfile checks the resulting profile against the policy.
+func (p ProfilePolicy) ValidateProfile(v ProfileValues) error {
+	for _, f := range p.Required {
+		empty := false
+		switch f {
+		case ProfileFieldFirstName:
+			empty = strings.TrimSpace(v.FirstName) == ""
+		case ProfileFieldLastName:
+			empty = strings.TrimSpace(v.LastName) == ""
+		case ProfileFieldBirthDate:
+			empty = v.BirthDate.IsZero()
+		case ProfileFieldAvatarURL:
+			empty = v.AvatarURL == ""
+		}
+		if empty {
+			return fmt.Errorf("el campo %s es obligatorio", f)
+		}
+	}
+
+	maxName := p.NameMaxLength
+	if maxName == 0 {
+		maxName = profileNameMaxLength
+	}
+	// Slice y no map: con ambos nombres inválidos el error debe ser siempre el mismo
+	for _, name := range []struct{ field, value string }{
+		{ProfileFieldFirstName, v.FirstName},
+		{ProfileFieldLastName, v.LastName},
+	} {
+		length := len([]rune(name.value))
+		if length == 0 {
+			continue
+		}
+		if length < p.NameMinLength || length > maxName {
+			return fmt.Errorf("el campo %s debe tener entre %d y %d caracteres", name.field, p.NameMinLength, maxName)
+		}
+	}
+
+	if !v.BirthDate.IsZero() {
+		now := time.Now()
+		if v.BirthDate.After(now) {
+			return fmt.Errorf("la fecha de nacimiento no puede ser futura")
+		}
+		if p.MinAge > 0 && v.BirthDate.AddDate(p.MinAge, 0, 0).After(now) {
+			return fmt.Errorf("debes tener al menos %d años", p.MinAge)
+		}
+	}
+
+	if v.AvatarURL != "" {
+		if len(v.AvatarURL) > profileAvatarMaxLength {
+			return fmt.Errorf("la URL del avatar no puede superar los %d caracteres", profileAvatarMaxLength)
+		}
+		u, err := url.Parse(v.AvatarURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("la URL del avatar no es válida")
+		}
+		if p.AvatarRequireHTTPS && u.Scheme != "https" {
+			return fmt.Errorf("la URL del avatar debe usar https")
+		}
+		if len(p.AvatarAllowedHosts) > 0 && !slices.Contains(p.AvatarAllowedHosts, strings.ToLower(u.Hostname())) {
+			return fmt.Errorf("el host del avatar no está permitido")
+		}
+	}
+	return nil
+}
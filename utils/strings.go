@@ -10,6 +10,12 @@ import (
 	"golang.org/x/text/unicode/norm"
 )
 
+// NormalizeEmail devuelve la forma canónica de un email para guardarlo y compararlo:
+// sin espacios y en minúsculas (la identidad de la cuenta no distingue mayúsculas).
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func Slugify(s string) string {
 	// 1. Normalizar para separar tildes de letras (e.g., 'í' -> 'i' + '´')
 	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Identifiers that LOGIN_POLICY can allow.
+const (
+	LoginIdentifierEmail    = "email"
+	LoginIdentifierUsername = "username"
+)
+
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 30 // varchar(30) en users
+)
+
+// Letras, números, punto, guión y guión bajo; empieza y termina con letra o número.
+var usernamePattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9._-]*[a-z0-9])?$`)
+
+// reservedUsernames no pueden registrarse para evitar suplantar cuentas del sistema o rutas.
+var reservedUsernames = []string{
+	"admin", "administrator", "root", "system", "sistema", "support", "soporte", "help", "ayuda",
+	"security", "seguridad", "api", "auth", "login", "logout", "register", "me", "null", "undefined",
+	"peak-auth", "peakauth", "postmaster", "webmaster", "noreply", "no-reply", "abuse",
+}
+
+// LoginPolicy is the LOGIN_POLICY rule: which identifiers the login accepts.
+// Without the rule only the email is accepted.
+type LoginPolicy struct {
+	Identifiers []string `json:"identifiers"`
+}
+
+// ParseLoginPolicy extracts the login policy and rejects unknown identifiers
+func ParseLoginPolicy(raw []byte) (*LoginPolicy, error) {
+	var r LoginPolicy
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_POLICY rule: %w", err)
+	}
+	if len(r.Identifiers) == 0 {
+		return nil, fmt.Errorf("invalid LOGIN_POLICY rule: debe permitir al menos un identificador")
+	}
+	for _, id := range r.Identifiers {
+		if id != LoginIdentifierEmail && id != LoginIdentifierUsername {
+			return nil, fmt.Errorf("invalid LOGIN_POLICY rule: identificador desconocido %q", id)
+		}
+	}
+	return &r, nil
+}
+
+// Allows reports whether the login accepts the identifier.
+func (p LoginPolicy) Allows(identifier string) bool {
+	if len(p.Identifiers) == 0 {
+		return identifier == LoginIdentifierEmail
+	}
+	return slices.Contains(p.Identifiers, identifier)
+}
+
+// NormalizeUsername returns the canonical form stored and compared: trimmed and lowercase.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// ValidateUsername checks an already normalized username against the charset and reserved words.
+func ValidateUsername(username string) error {
+	if l := len(username); l < UsernameMinLength || l > UsernameMaxLength {
+		return fmt.Errorf("el nombre de usuario debe tener entre %d y %d caracteres", UsernameMinLength, UsernameMaxLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("el nombre de usuario sólo admite letras, números, punto, guión y guión bajo, y debe empezar y terminar con letra o número")
+	}
+	if slices.Contains(reservedUsernames, username) {
+		return fmt.Errorf("el nombre de usuario %q está reservado", username)
+	}
+	return nil
+}
+
+// IsEmailIdentifier reports whether a login identifier is an email rather than a username
+// (a username can never contain "@").
+func IsEmailIdentifier(identifier string) bool {
+	return strings.Contains(identifier, "@")
+}
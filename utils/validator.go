@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"time"
 )
 
 type RegistrationPolicy struct {
@@ -17,11 +18,64 @@ type PasswordPolicy struct {
 	RequireUppercase bool `json:"require_uppercase"`
 	RequireNumbers   bool `json:"require_numbers"`
 	RequireSymbols   bool `json:"require_symbols"`
+	MinScore         int  `json:"min_score"` // 0-4 según EstimatePasswordStrength
 }
 
 type SessionPolicy struct {
 	TokenExpirationMinutes int `json:"token_expiration_minutes"`
 	MaxFailedLogins        int `json:"max_failed_logins"`
+	LockoutMinutes         int `json:"lockout_minutes"`     // Duración del primer bloqueo
+	MaxLockoutMinutes      int `json:"max_lockout_minutes"` // Tope del backoff exponencial
+
+	// Desafío (proof-of-work o CAPTCHA) exigido en el login ante fallos sospechosos. 0 lo desactiva.
+	ChallengeAfterFailures   int    `json:"challenge_after_failures"`    // Fallos recientes con el mismo email/username en la app
+	ChallengeAfterIPFailures int    `json:"challenge_after_ip_failures"` // Fallos desde la misma IP
+	ChallengeDifficulty      int    `json:"challenge_difficulty"`        // Bits en cero del proof-of-work
+	ChallengeType            string `json:"challenge_type"`              // "pow" (default) o "captcha"
+}
+
+// LockoutDuration returns the lock window for the n-th consecutive lockout (1-based),
+// doubling the base window each time up to MaxLockoutMinutes.
+func (p SessionPolicy) LockoutDuration(lockCount uint) time.Duration {
+	base := p.LockoutMinutes
+	if base <= 0 {
+		base = 15
+	}
+	limit := p.MaxLockoutMinutes
+	if limit <= 0 {
+		limit = 24 * 60
+	}
+	minutes := base
+	for i := uint(1); i < lockCount && minutes < limit; i++ {
+		minutes *= 2
+	}
+	if minutes > limit {
+		minutes = limit
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// RateLimitSpec is a token bucket: Requests per WindowSeconds, with an optional Burst.
+// Requests <= 0 disables that dimension for the route.
+type RateLimitSpec struct {
+	Requests      int `json:"requests"`
+	WindowSeconds int `json:"window_seconds"`
+	Burst         int `json:"burst"`
+}
+
+// RouteRateLimit groups the limits applied per client IP, per email, per application and per user.
+// The per-application limit only applies to routes authenticated with the app secret, and the
+// per-user limit only to routes authenticated with an access token.
+type RouteRateLimit struct {
+	IP    *RateLimitSpec `json:"ip"`
+	Email *RateLimitSpec `json:"email"`
+	App   *RateLimitSpec `json:"app"`
+	User  *RateLimitSpec `json:"user"`
+}
+
+// RateLimitPolicy overrides the default limits of each public route (login, register...).
+type RateLimitPolicy struct {
+	Routes map[string]RouteRateLimit `json:"routes"`
 }
 
 type AuthzPolicy struct {
@@ -51,7 +105,8 @@ func ParseRegistrationPolicy(raw []byte) (*RegistrationPolicy, error) {
 }
 
 // ValidatePasswordPolicy checks if a plaintext password satisfies the configured constraints.
-func ValidatePasswordPolicy(raw []byte, password string) error {
+// userInputs (email, app name...) are treated as dictionary words by the strength estimator.
+func ValidatePasswordPolicy(raw []byte, password string, userInputs ...string) error {
 	var r PasswordPolicy
 	if err := json.Unmarshal(raw, &r); err != nil {
 		return fmt.Errorf("invalid PWD_POLICY rule: %w", err)
@@ -78,9 +133,27 @@ func ValidatePasswordPolicy(raw []byte, password string) error {
 			return fmt.Errorf("la contraseña debe contener al menos un símbolo")
 		}
 	}
+	if r.MinScore > 0 {
+		strength := EstimatePasswordStrength(password, userInputs...)
+		if strength.Score < r.MinScore {
+			if strength.Warning != "" {
+				return fmt.Errorf("la contraseña es demasiado débil (%d/4, mínimo %d): %s", strength.Score, r.MinScore, strength.Warning)
+			}
+			return fmt.Errorf("la contraseña es demasiado débil (%d/4, mínimo %d)", strength.Score, r.MinScore)
+		}
+	}
 	return nil
 }
 
+// ParsePasswordPolicy extracts the password policy without validating any password
+func ParsePasswordPolicy(raw []byte) (*PasswordPolicy, error) {
+	var r PasswordPolicy
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("invalid PWD_POLICY rule: %w", err)
+	}
+	return &r, nil
+}
+
 // ParseSessionPolicy extracts session configuration rules such as token expiration
 func ParseSessionPolicy(raw []byte) (*SessionPolicy, error) {
 	var r SessionPolicy
@@ -90,6 +163,15 @@ func ParseSessionPolicy(raw []byte) (*SessionPolicy, error) {
 	return &r, nil
 }
 
+// ParseRateLimitPolicy extracts per-route rate limit overrides
+func ParseRateLimitPolicy(raw []byte) (*RateLimitPolicy, error) {
+	var r RateLimitPolicy
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_POLICY rule: %w", err)
+	}
+	return &r, nil
+}
+
 // ParseAuthzPolicy extracts authorization constraints
 func ParseAuthzPolicy(raw []byte) (*AuthzPolicy, error) {
 	var r AuthzPolicy